@@ -0,0 +1,106 @@
+package commandgo
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Alias gives the given key other names, its aliases, which are matched in the command line as if they were the key.
+// The key remains the canonical name of the command or flag, which help lists along with its aliases.
+// Aliases are not keys of the map, so when an alias is also mapped as a key to a different command, Run reports the conflict.
+// panics if the key is not mapped or an alias is already an alias of another key.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Alias(key string, aliases ...string) Commands {
+	if _, ok := c[key]; !ok {
+		panic(fmt.Sprintf("alias key %s is not mapped", key))
+	}
+	s := c.ensureSettings()
+	if s.aliases == nil {
+		s.aliases = map[string]string{}
+	}
+	for _, al := range aliases {
+		if k, ok := s.aliases[al]; ok && k != key {
+			panic(fmt.Sprintf("%s is already an alias of %s", al, k))
+		}
+		s.aliases[al] = key
+	}
+	return c
+}
+
+// Aliases gets the other names of the given key, both those declared with Alias and any other keys mapped to the same assignment.
+// returns the aliases in name order.
+func (c Commands) Aliases(key string) []string {
+	cmd, ok := c[key]
+	if !ok {
+		return nil
+	}
+	var names []string
+	for al, k := range c.settings().aliases {
+		if k == key {
+			names = append(names, al)
+		}
+	}
+	if c.isAssignment(cmd) {
+		for _, k := range c.sortedKeys() {
+			if kc := c[k]; k != key && c.isAssignment(kc) && kc == cmd && !containsString(names, k) {
+				names = append(names, k)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findAlias finds the key of the given alias, preferring an exact match over a case insensitive one.
+func (c Commands) findAlias(arg string) (string, bool) {
+	aliases := c.settings().aliases
+	if k, ok := aliases[arg]; ok {
+		return k, true
+	}
+	var found []string
+	for al := range aliases {
+		if strings.EqualFold(al, arg) {
+			found = append(found, al)
+		}
+	}
+	if len(found) == 0 {
+		return "", false
+	}
+	sort.Strings(found)
+	return aliases[found[0]], true
+}
+
+// checkAliases checks the aliases of this map refer to mapped keys and do not collide with keys mapped to other commands.
+func (c Commands) checkAliases() error {
+	aliases := c.settings().aliases
+	names := make([]string, 0, len(aliases))
+	for al := range aliases {
+		names = append(names, al)
+	}
+	sort.Strings(names)
+	for _, al := range names {
+		key := aliases[al]
+		cmd, ok := c[key]
+		if !ok {
+			return fmt.Errorf("alias %s refers to %s, which is not mapped", al, key)
+		}
+		ac, ok := c[al]
+		if !ok {
+			continue
+		}
+		if !c.isAssignment(cmd) || !c.isAssignment(ac) || ac != cmd {
+			return fmt.Errorf("alias %s of %s conflicts with the key %s", al, key, al)
+		}
+	}
+	return nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, sz := range ss {
+		if sz == s {
+			return true
+		}
+	}
+	return false
+}
@@ -2,9 +2,14 @@ package arguments
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
+// Terminator marks the end of the flags in a command line.
+// All arguments following it are parameters, even those beginning with a '-'.
+const Terminator = "--"
+
 type Arguments interface {
 	// Command gets the first argument from the command line, only if it is NOT a flag.
 	// If the cmd line begins with a flag argument, command returns empty
@@ -16,19 +21,23 @@ type Arguments interface {
 	Argument(name string) *Argument
 
 	// Flags gets all the Arguments with their parameters, with names begining with a '-'
+	// Negative numbers are not flags and no flags are collected following a Terminator.
 	Flags() []*Argument
 
 	// Remove removes the given argument from the command line.
 	// The argument and all its parameters are removed from any further queirs to the Arguments.
 	Remove(arg *Argument) error
 
-	// CommandLine gets the complete command line
+	// CommandLine gets the complete command line, including any Terminator
 	CommandLine() []string
 }
 
 type Argument struct {
 	Name       string
 	Parameters []string
+
+	// Inline is true when the parameter was given as part of the flag itself, e.g. --name=value
+	Inline bool
 }
 
 func (a arguments) CommandLine() []string {
@@ -37,6 +46,7 @@ func (a arguments) CommandLine() []string {
 
 type arguments struct {
 	cmdline []string
+	isFlag  func(s string) bool
 }
 
 func (a arguments) IsEmpty() bool {
@@ -44,7 +54,7 @@ func (a arguments) IsEmpty() bool {
 }
 
 func (a arguments) Command() string {
-	if a.IsEmpty() || strings.HasPrefix(a.cmdline[0], "-") {
+	if a.IsEmpty() || a.isFlag(a.cmdline[0]) || a.cmdline[0] == Terminator {
 		// no command, all flags or empty
 		return ""
 	}
@@ -54,12 +64,13 @@ func (a arguments) Command() string {
 func (a arguments) Flags() []*Argument {
 	var flags []*Argument
 	for i, cmd := range a.cmdline {
-		if !strings.HasPrefix(cmd, "-") {
+		if cmd == Terminator {
+			break
+		}
+		if !a.isFlag(cmd) {
 			continue
 		}
-		arg := a.newArg(cmd, i)
-		i += len(arg.Parameters)
-		flags = append(flags, arg)
+		flags = append(flags, a.newArg(cmd, i))
 	}
 	return flags
 }
@@ -69,11 +80,15 @@ func (a arguments) Flags() []*Argument {
 // a '-'flag arg is encountered.
 // e.g. cmd dothisthing -flag1 hello world -flag2 false
 // cmd has a single string parameter, -flag1 has 2 string parameters, -flag2 has a single bool param.
+// A flag given with an inline value, e.g. -flag1=hello, has that value as its only parameter.
 func (a arguments) Argument(name string) *Argument {
 	for i, arg := range a.cmdline {
 		if strings.EqualFold(arg, name) {
 			return a.newArg(name, i)
 		}
+		if n, _, ok := splitInline(arg); ok && strings.EqualFold(n, name) {
+			return a.newArg(arg, i)
+		}
 	}
 	return nil
 }
@@ -83,6 +98,11 @@ func (a *arguments) Remove(arg *Argument) error {
 	if i < 0 {
 		return fmt.Errorf("unknown argument %s", arg.Name)
 	}
+	if arg.Inline {
+		// parameter is part of the flag argument
+		a.cmdline = append(a.cmdline[:i], a.cmdline[i+1:]...)
+		return nil
+	}
 	if i+len(arg.Parameters) >= len(a.cmdline) {
 		return fmt.Errorf("invaid arg %s. parameters not found in command line", arg.Name)
 	}
@@ -91,12 +111,12 @@ func (a *arguments) Remove(arg *Argument) error {
 }
 
 // parameters colelcts all the arguments following the given position, if any.
-// parameters are all arguments following which do NOT start with a '-' flag indicator.
+// parameters are all arguments following which are NOT flags, up to any Terminator.
 func (a arguments) parameters(position int) []string {
 	var params []string
 	for i := position + 1; i < len(a.cmdline); i++ {
-		// Stop gathering parameters at the next flag or end of cmdline
-		if strings.HasPrefix(a.cmdline[i], "-") {
+		// Stop gathering parameters at the next flag, terminator or end of cmdline
+		if a.isFlag(a.cmdline[i]) || a.cmdline[i] == Terminator {
 			break
 		}
 		params = append(params, a.cmdline[i])
@@ -105,21 +125,86 @@ func (a arguments) parameters(position int) []string {
 }
 
 func (a arguments) newArg(name string, position int) *Argument {
+	if n, v, ok := splitInline(name); ok {
+		return &Argument{
+			Name:       n,
+			Parameters: []string{v},
+			Inline:     true,
+		}
+	}
 	return &Argument{
 		Name:       name,
 		Parameters: a.parameters(position),
 	}
 }
 
+// Position gets the index of the given argument in the command line or -1 if not found.
+// Inline arguments are matched on the name part of the flag argument.
 func (a *arguments) Position(arg *Argument) int {
-	for i, a := range a.cmdline {
-		if a == arg.Name {
+	for i, s := range a.cmdline {
+		if !arg.Inline {
+			if s == arg.Name {
+				return i
+			}
+			continue
+		}
+		if n, _, ok := splitInline(s); ok && n == arg.Name {
 			return i
 		}
 	}
 	return -1
 }
 
+// splitInline splits a flag argument containing an inline value, e.g. --name=value, into its name and value.
+// The value is everything following the first '=', so may itself begin with a '-' or contain further '='.
+// returns false if the argument is not a flag or has no inline value.
+func splitInline(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, "-") {
+		return "", "", false
+	}
+	i := strings.Index(s, "=")
+	if i < 0 || strings.TrimLeft(s[:i], "-") == "" {
+		return "", "", false
+	}
+	return s[:i], s[i+1:], true
+}
+
+// IsFlag checks if the given argument is a flag.
+// Flags begin with a '-', with the exception of the Terminator and negative numbers, which are treated as values.
+// Use NewArgumentsFunc to decide which negative numbers are flags.
+func IsFlag(s string) bool {
+	if !strings.HasPrefix(s, "-") || s == Terminator {
+		return false
+	}
+	return !IsNumber(s)
+}
+
+// StripTerminator removes the first Terminator from the given arguments, if present.
+func StripTerminator(args []string) []string {
+	for i, arg := range args {
+		if arg == Terminator {
+			return append(append([]string{}, args[:i]...), args[i+1:]...)
+		}
+	}
+	return args
+}
+
+// IsNumber checks if the given argument is a number, such as -5 or -0.5
+func IsNumber(s string) bool {
+	n := strings.TrimPrefix(s, "-")
+	if n == "" || (n[0] != '.' && (n[0] < '0' || n[0] > '9')) {
+		return false
+	}
+	_, err := strconv.ParseFloat(n, 64)
+	return err == nil
+}
+
 func NewArguments(args []string) Arguments {
-	return &arguments{cmdline: args}
+	return NewArgumentsFunc(args, IsFlag)
+}
+
+// NewArgumentsFunc creates Arguments using the given function, in place of IsFlag, to decide which arguments are flags.
+// Allows the negative numbers which are flags to be decided by where they are used.
+func NewArgumentsFunc(args []string, isFlag func(s string) bool) Arguments {
+	return &arguments{cmdline: args, isFlag: isFlag}
 }
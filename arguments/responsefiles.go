@@ -0,0 +1,91 @@
+package arguments
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ResponseFilePrefix marks an argument as a response file, a file containing further arguments.
+// e.g. @args.txt is replaced by the arguments in the file args.txt
+// An argument beginning with two prefixes, e.g. @@literal, is not a response file and has the first prefix removed.
+const ResponseFilePrefix = "@"
+
+// ExpandResponseFiles replaces any response file arguments with the arguments read from that file.
+// The file contents are split into arguments as a shell would, using quotes and backslash escapes.
+// Response files may contain further response files, with relative paths being relative to the file containing them.
+// returns an error if a file can not be read, can not be split or includes itself.
+// No arguments following a Terminator are expanded.
+func ExpandResponseFiles(args []string) ([]string, error) {
+	expanded, _, err := expandResponseFiles(args, "", nil)
+	return expanded, err
+}
+
+// expandResponseFiles expands the response files in the given arguments.
+// dir is the directory relative paths are resolved to and opened the response files currently being expanded.
+// returns the expanded arguments and true if a Terminator was found.
+func expandResponseFiles(args []string, dir string, opened []string) ([]string, bool, error) {
+	var expanded []string
+	for i, arg := range args {
+		if arg == Terminator {
+			return append(expanded, args[i:]...), true, nil
+		}
+		if !strings.HasPrefix(arg, ResponseFilePrefix) || arg == ResponseFilePrefix {
+			expanded = append(expanded, arg)
+			continue
+		}
+		arg = strings.TrimPrefix(arg, ResponseFilePrefix)
+		if strings.HasPrefix(arg, ResponseFilePrefix) {
+			// escaped, not a file
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		fargs, err := readResponseFile(arg, dir, opened)
+		if err != nil {
+			return nil, false, err
+		}
+		expanded = append(expanded, fargs...)
+		if containsTerminator(fargs) {
+			return append(expanded, args[i+1:]...), true, nil
+		}
+	}
+	return expanded, false, nil
+}
+
+// readResponseFile reads the arguments from the given response file, expanding any response files it contains.
+func readResponseFile(path string, dir string, opened []string) ([]string, error) {
+	if dir != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range opened {
+		if o == abs {
+			return nil, fmt.Errorf("response file %s includes itself", path)
+		}
+	}
+
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("response file %v", err)
+	}
+	args, err := SplitLine(string(by))
+	if err != nil {
+		return nil, fmt.Errorf("response file %s, %v", path, err)
+	}
+	args, _, err = expandResponseFiles(args, filepath.Dir(abs), append(opened, abs))
+	return args, err
+}
+
+func containsTerminator(args []string) bool {
+	for _, arg := range args {
+		if arg == Terminator {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package arguments_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/arguments"
+)
+
+func TestExpandResponseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "responsefiles")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"args.txt":     "--name 'two words' # a comment\n\"quoted \\\"name\\\"\" a\\ b \\\n-x @sub/more.txt",
+		"sub/more.txt": "-y=1 -- @notexpanded",
+		"self.txt":     "-z @self.txt",
+		"broken.txt":   "-z 'unterminated",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+	}
+
+	args, err := arguments.ExpandResponseFiles([]string{"cmd", "@" + filepath.Join(dir, "args.txt"), "@@literal", "last"})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	expect := []string{"cmd", "--name", "two words", `quoted "name"`, "a b", "-x", "-y=1", "--", "@notexpanded", "@@literal", "last"}
+	if strings.Join(args, "|") != strings.Join(expect, "|") {
+		t.Fatalf("unexpected arguments, expected %q, found %q", expect, args)
+	}
+
+	args, err = arguments.ExpandResponseFiles([]string{"@@literal", "@", "--", "@" + filepath.Join(dir, "args.txt")})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	expect = []string{"@literal", "@", "--", "@" + filepath.Join(dir, "args.txt")}
+	if strings.Join(args, "|") != strings.Join(expect, "|") {
+		t.Fatalf("unexpected arguments, expected %q, found %q", expect, args)
+	}
+
+	for _, name := range []string{"self.txt", "broken.txt", "missing.txt"} {
+		if _, err := arguments.ExpandResponseFiles([]string{"@" + filepath.Join(dir, name)}); err == nil {
+			t.Fatalf("expected error expanding %s", name)
+		}
+	}
+}
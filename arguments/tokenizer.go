@@ -0,0 +1,90 @@
+package arguments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SplitLine splits the given line into arguments, following the quoting rules of a posix shell.
+// Arguments are separated by whitespace, which may be included in an argument using quotes or a backslash escape.
+// Single quotes preserve everything between them, double quotes preserve all but a backslash escaped ", \, $ or `.
+// An unquoted backslash escapes the following character and a backslash followed by a newline continues the line.
+// A '#' at the start of an argument is a comment, ignoring the remainder of that line.
+// returns an error if a quote is not closed or the line ends with an escape.
+func SplitLine(line string) ([]string, error) {
+	var args []string
+	word := strings.Builder{}
+	var inWord, escaped, comment bool
+	var quote rune
+	var quoteStart int
+
+	for i, r := range line {
+		switch {
+		case comment:
+			if r == '\n' {
+				comment = false
+			}
+
+		case escaped:
+			escaped = false
+			if r == '\n' {
+				// line continuation
+				continue
+			}
+			if quote == '"' && !strings.ContainsRune("\"\\$`", r) {
+				// not an escape within double quotes, backslash is kept
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			inWord = true
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+
+		case r == '\\':
+			escaped = true
+
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+				continue
+			}
+			word.WriteRune(r)
+
+		case r == '\'' || r == '"':
+			quote = r
+			quoteStart = i
+			inWord = true
+
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case r == '#' && !inWord:
+			comment = true
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote, starting at position %d", quote, quoteStart)
+	}
+	if escaped {
+		return nil, errors.New("unterminated escape at end of line")
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
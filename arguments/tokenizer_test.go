@@ -0,0 +1,38 @@
+package arguments_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/arguments"
+)
+
+func TestSplitLine(t *testing.T) {
+	tests := map[string][]string{
+		``:                              nil,
+		`  one   two  `:                 {"one", "two"},
+		`one 'two three' four`:          {"one", "two three", "four"},
+		`"two \"three\"" 'a\b'`:         {`two "three"`, `a\b`},
+		`"a\b" a\ b`:                    {`a\b`, "a b"},
+		`''  ""`:                        {"", ""},
+		`one#two # a comment`:           {"one#two"},
+		"one \\\ntwo":                   {"one", "two"},
+		"one # comment\ntwo":            {"one", "two"},
+		`--name="with space" -x='-y z'`: {"--name=with space", "-x=-y z"},
+	}
+	for line, expect := range tests {
+		args, err := arguments.SplitLine(line)
+		if err != nil {
+			t.Fatalf("unexpected error splitting %q, %v", line, err)
+		}
+		if len(args) != len(expect) || strings.Join(args, "|") != strings.Join(expect, "|") {
+			t.Fatalf("unexpected arguments splitting %q, expected %q, found %q", line, expect, args)
+		}
+	}
+
+	for _, line := range []string{`one "two`, `one 'two`, `one two\`} {
+		if _, err := arguments.SplitLine(line); err == nil {
+			t.Fatalf("expected error splitting %q", line)
+		}
+	}
+}
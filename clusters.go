@@ -0,0 +1,138 @@
+package commandgo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/eurozulu/commandgo/arguments"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// shortFlag is a single letter flag, collected from a map or one of its sub maps.
+type shortFlag struct {
+	cmd     interface{}
+	counter bool
+}
+
+// expandClusters expands any clustered, single letter flags in the given arguments into their individual flags.
+// When all is false, only clusters of counter flags, such as -vvv are expanded.
+// Arguments which do not expand entirely into known flags are left unchanged.
+func (c Commands) expandClusters(args []string, all bool) ([]string, error) {
+	short := map[string]*shortFlag{}
+	c.collectShortFlags(short)
+
+	var expanded []string
+	for i, arg := range args {
+		if arg == arguments.Terminator {
+			return append(expanded, args[i:]...), nil
+		}
+		flags, err := c.expandCluster(arg, short, all)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, flags...)
+	}
+	return expanded, nil
+}
+
+// expandCluster expands a single argument into the flags it contains.
+// returns the argument unchanged if it is not a cluster
+func (c Commands) expandCluster(arg string, short map[string]*shortFlag, all bool) ([]string, error) {
+	if !isCluster(arg) {
+		return []string{arg}, nil
+	}
+	var flags []string
+	rest := arg[1:]
+	for rest != "" {
+		r, size := utf8.DecodeRuneInString(rest)
+		rest = rest[size:]
+		k, sf, ok := findShortFlag(string([]rune{'-', r}), short)
+		if !ok || (!all && !sf.counter) {
+			// not every letter is a flag, not a cluster
+			return []string{arg}, nil
+		}
+		if rest != "" && !sf.counter && c.isAssignment(sf.cmd) && !values.IsKind(sf.cmd, reflect.Bool) {
+			// remainder of cluster is the value of the last flag
+			flags = append(flags, strings.Join([]string{k, rest}, "="))
+			break
+		}
+		flags = append(flags, k)
+	}
+	if c.treeHasKey(arg) {
+		return nil, fmt.Errorf("ambiguous flag %s, could be %s or %s", arg, arg, strings.Join(flags, " "))
+	}
+	return flags, nil
+}
+
+// collectShortFlags collects all the single letter flags from this map and any sub maps.
+// Flags in a parent map take priority over the same flag in a sub map.
+func (c Commands) collectShortFlags(short map[string]*shortFlag) {
+	var subs []string
+	for _, k := range c.sortedKeys() {
+		cmd := c[k]
+		if c.isSubmap(cmd) {
+			subs = append(subs, k)
+			continue
+		}
+		if !strings.HasPrefix(k, "-") || utf8.RuneCountInString(k) != 2 {
+			continue
+		}
+		if _, ok := short[k]; !ok {
+			short[k] = &shortFlag{cmd: cmd, counter: c.isCounter(k)}
+		}
+	}
+	var aliases []string
+	for al := range c.settings().aliases {
+		aliases = append(aliases, al)
+	}
+	sort.Strings(aliases)
+	for _, al := range aliases {
+		k := c.settings().aliases[al]
+		if !strings.HasPrefix(al, "-") || utf8.RuneCountInString(al) != 2 || c.isSubmap(c[k]) {
+			continue
+		}
+		if _, ok := short[al]; !ok {
+			short[al] = &shortFlag{cmd: c[k], counter: c.isCounter(k)}
+		}
+	}
+	sort.Strings(subs)
+	for _, k := range subs {
+		c[k].(Commands).collectShortFlags(short)
+	}
+}
+
+// treeHasKey checks if the given key is mapped in this map or any of its sub maps
+func (c Commands) treeHasKey(key string) bool {
+	if _, ok := c.findKey(key); ok {
+		return true
+	}
+	for _, k := range c.sortedKeys() {
+		if cmd := c[k]; c.isSubmap(cmd) && cmd.(Commands).treeHasKey(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// findShortFlag finds the given flag in the collected short flags, preferring an exact match over a case insensitive one.
+func findShortFlag(flag string, short map[string]*shortFlag) (string, *shortFlag, bool) {
+	if sf, ok := short[flag]; ok {
+		return flag, sf, true
+	}
+	for k, sf := range short {
+		if strings.EqualFold(k, flag) {
+			return k, sf, true
+		}
+	}
+	return "", nil, false
+}
+
+// isCluster checks if the given argument could be a cluster of single letter flags.
+// i.e. a single dash followed by two or more characters, without an inline value.
+func isCluster(arg string) bool {
+	return arguments.IsFlag(arg) && !strings.HasPrefix(arg, "--") &&
+		utf8.RuneCountInString(arg) > 2 && !strings.Contains(arg, "=")
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+
+	"github.com/eurozulu/commandgo/help"
+)
+
+const commandgoPath = "github.com/eurozulu/commandgo"
+
+// extractor finds the Commands literals of a package and the doc comments of what their keys are mapped to.
+type extractor struct {
+	fset *token.FileSet
+	info *types.Info
+
+	// maps are the Commands literals, in source order
+	maps []*ast.CompositeLit
+	// vars are the variables assigned Commands literals
+	vars map[types.Object]*ast.CompositeLit
+	// docs are the files declaring mapped objects, parsed with their comments, keyed by file name.
+	docs map[string]*ast.File
+}
+
+// Extract parses the package in the given directory, finding the help of its Commands maps.
+// The root maps form the "main" subject, sub maps a subject named by the keys leading to them, delimited by a space.
+// Keys whose target has no doc comment are not included. The doc of the "" key target becomes the comment of its subject.
+// exclude is the name of a file in the directory to ignore, such as a previously generated file.
+// returns the name of the package and its help subjects, or an error listing every type error, should the package not type check.
+func Extract(dir string, exclude string) (string, []*help.HelpSubject, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return fi.Name() != exclude && !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(pkgs) != 1 {
+		return "", nil, fmt.Errorf("expected a single package in %s, found %d", dir, len(pkgs))
+	}
+	var pkg *ast.Package
+	for _, p := range pkgs {
+		pkg = p
+	}
+	var files []*ast.File
+	for _, f := range pkg.Files {
+		files = append(files, f)
+	}
+
+	e := &extractor{
+		fset: fset,
+		info: &types.Info{
+			Types:      map[ast.Expr]types.TypeAndValue{},
+			Defs:       map[*ast.Ident]types.Object{},
+			Uses:       map[*ast.Ident]types.Object{},
+			Selections: map[*ast.SelectorExpr]*types.Selection{},
+		},
+		vars: map[types.Object]*ast.CompositeLit{},
+		docs: map[string]*ast.File{},
+	}
+	var errs []string
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		// collect every error, rather than stopping at the first
+		Error: func(err error) {
+			errs = append(errs, err.Error())
+		},
+	}
+	conf.Check(pkg.Name, fset, files, e.info)
+	if len(errs) > 0 {
+		return "", nil, fmt.Errorf("package %s does not type check:\n%s", pkg.Name, strings.Join(errs, "\n"))
+	}
+	for _, f := range files {
+		e.findMaps(f)
+	}
+	return pkg.Name, e.subjects(), nil
+}
+
+// findMaps finds the Commands literals in the given file, and the variables they are assigned to.
+func (e *extractor) findMaps(f *ast.File) {
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch x := n.(type) {
+		case *ast.CompositeLit:
+			if e.isCommands(x) {
+				e.maps = append(e.maps, x)
+			}
+		case *ast.ValueSpec:
+			for i, v := range x.Values {
+				if lit := e.literal(v); lit != nil && i < len(x.Names) {
+					e.vars[e.info.Defs[x.Names[i]]] = lit
+				}
+			}
+		case *ast.AssignStmt:
+			for i, v := range x.Rhs {
+				id, ok := x.Lhs[i].(*ast.Ident)
+				lit := e.literal(v)
+				if !ok || lit == nil || len(x.Lhs) != len(x.Rhs) {
+					continue
+				}
+				if obj := e.info.Defs[id]; obj != nil {
+					e.vars[obj] = lit
+				} else if obj = e.info.Uses[id]; obj != nil {
+					e.vars[obj] = lit
+				}
+			}
+		}
+		return true
+	})
+}
+
+// isCommands checks if the given literal is of the commandgo.Commands type
+func (e *extractor) isCommands(lit *ast.CompositeLit) bool {
+	sel, ok := lit.Type.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Commands" {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	pn, ok := e.info.Uses[id].(*types.PkgName)
+	return ok && pn.Imported().Path() == commandgoPath
+}
+
+// literal gets the Commands literal of the given expression, following any methods chained on it, such as Alias.
+// returns nil if the expression is not a Commands literal
+func (e *extractor) literal(x ast.Expr) *ast.CompositeLit {
+	switch v := x.(type) {
+	case *ast.ParenExpr:
+		return e.literal(v.X)
+	case *ast.CompositeLit:
+		if e.isCommands(v) {
+			return v
+		}
+	case *ast.CallExpr:
+		if sel, ok := v.Fun.(*ast.SelectorExpr); ok {
+			return e.literal(sel.X)
+		}
+	}
+	return nil
+}
+
+// submap gets the Commands literal a key is mapped to, either directly or by a variable assigned the literal.
+// returns nil if the key is not mapped to a sub map.
+func (e *extractor) submap(x ast.Expr) *ast.CompositeLit {
+	if lit := e.literal(x); lit != nil {
+		return lit
+	}
+	if id, ok := unchained(x).(*ast.Ident); ok {
+		return e.vars[e.info.Uses[id]]
+	}
+	return nil
+}
+
+// subjects gets the subjects of all the root maps, those not mapped as a sub map of another.
+func (e *extractor) subjects() []*help.HelpSubject {
+	subs := map[*ast.CompositeLit]bool{}
+	for _, lit := range e.maps {
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if sub := e.submap(kv.Value); sub != nil {
+					subs[sub] = true
+				}
+			}
+		}
+	}
+	var subjects []*help.HelpSubject
+	for _, lit := range e.maps {
+		if !subs[lit] {
+			subjects = merge(subjects, e.mapSubjects(nil, lit)...)
+		}
+	}
+	return subjects
+}
+
+// mapSubjects gets the subject of the given map, at the given path of keys, followed by those of its sub maps.
+func (e *extractor) mapSubjects(path []string, lit *ast.CompositeLit) []*help.HelpSubject {
+	hs := &help.HelpSubject{Name: "main"}
+	if len(path) > 0 {
+		hs.Name = strings.Join(path, " ")
+	}
+	var subjects []*help.HelpSubject
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		tv, ok := e.info.Types[kv.Key]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			continue
+		}
+		key := constant.StringVal(tv.Value)
+
+		var comment string
+		if sub := e.submap(kv.Value); sub != nil {
+			ss := e.mapSubjects(append(append([]string{}, path...), key), sub)
+			comment = ss[0].Comment
+			subjects = append(subjects, ss...)
+		} else {
+			comment = e.doc(kv.Value)
+		}
+		if key == "" {
+			hs.Comment = comment
+			continue
+		}
+		if comment != "" {
+			hs.HelpItems = append(hs.HelpItems, &help.HelpItem{Key: key, Comment: comment})
+		}
+	}
+	return append([]*help.HelpSubject{hs}, subjects...)
+}
+
+// doc gets the doc comment of the function, method, field or variable the given expression refers to.
+func (e *extractor) doc(x ast.Expr) string {
+	var obj types.Object
+	switch v := x.(type) {
+	case *ast.ParenExpr:
+		return e.doc(v.X)
+	case *ast.UnaryExpr:
+		return e.doc(v.X)
+	case *ast.Ident:
+		obj = e.info.Uses[v]
+	case *ast.SelectorExpr:
+		if sel, ok := e.info.Selections[v]; ok {
+			obj = sel.Obj()
+		} else {
+			obj = e.info.Uses[v.Sel]
+		}
+	}
+	if obj == nil || !obj.Pos().IsValid() {
+		return ""
+	}
+	return e.objectDoc(e.fset.Position(obj.Pos()))
+}
+
+// objectDoc gets the doc comment of the declaration, of a function, method, field or variable, named at the given position.
+// Fields and variables without a doc comment use their line comment.
+func (e *extractor) objectDoc(pos token.Position) string {
+	f, err := e.docFile(pos.Filename)
+	if err != nil {
+		return ""
+	}
+	var doc *ast.CommentGroup
+	ast.Inspect(f, func(n ast.Node) bool {
+		if doc != nil {
+			return false
+		}
+		switch v := n.(type) {
+		case *ast.FuncDecl:
+			if offset(v.Name) == pos.Offset {
+				doc = v.Doc
+			}
+		case *ast.GenDecl:
+			for _, s := range v.Specs {
+				vs, ok := s.(*ast.ValueSpec)
+				if !ok || !declares(vs.Names, pos) {
+					continue
+				}
+				doc = firstComment(vs.Doc, vs.Comment)
+				if doc == nil && len(v.Specs) == 1 {
+					doc = v.Doc
+				}
+			}
+		case *ast.Field:
+			if declares(v.Names, pos) {
+				doc = firstComment(v.Doc, v.Comment)
+			}
+		}
+		return true
+	})
+	if doc == nil {
+		return ""
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// docFile gets the named file, parsed with its comments.
+func (e *extractor) docFile(name string) (*ast.File, error) {
+	if f, ok := e.docs[name]; ok {
+		return f, nil
+	}
+	// parsed as the only file of its own file set, positions are the offset within the file, plus one.
+	f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	e.docs[name] = f
+	return f, nil
+}
+
+// declares checks if one of the given names is at the given position
+func declares(names []*ast.Ident, pos token.Position) bool {
+	for _, n := range names {
+		if offset(n) == pos.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+// offset gets the offset of the given node within its doc file.
+func offset(n ast.Node) int {
+	return int(n.Pos()) - 1
+}
+
+// unchained gets the expression any methods are chained on.
+func unchained(x ast.Expr) ast.Expr {
+	if c, ok := x.(*ast.CallExpr); ok {
+		if sel, ok := c.Fun.(*ast.SelectorExpr); ok {
+			return unchained(sel.X)
+		}
+	}
+	return x
+}
+
+// firstComment gets the first of the given comments which is not nil
+func firstComment(cgs ...*ast.CommentGroup) *ast.CommentGroup {
+	for _, cg := range cgs {
+		if cg != nil {
+			return cg
+		}
+	}
+	return nil
+}
+
+// merge adds the given subjects to those already found, merging the items of subjects with the same name.
+func merge(subjects []*help.HelpSubject, add ...*help.HelpSubject) []*help.HelpSubject {
+	for _, hs := range add {
+		var found *help.HelpSubject
+		for _, s := range subjects {
+			if s.Name == hs.Name {
+				found = s
+				break
+			}
+		}
+		if found == nil {
+			subjects = append(subjects, hs)
+			continue
+		}
+		if found.Comment == "" {
+			found.Comment = hs.Comment
+		}
+		found.HelpItems = append(found.HelpItems, hs.HelpItems...)
+	}
+	return subjects
+}
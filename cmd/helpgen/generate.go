@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"strings"
+
+	"github.com/eurozulu/commandgo/help"
+)
+
+// Generate writes the Go source, of the named package, adding the given subjects to the help library.
+func Generate(w io.Writer, pkg string, subjects []*help.HelpSubject) error {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintln(buf, "// Code generated by helpgen. DO NOT EDIT.")
+	fmt.Fprintln(buf)
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "import %q\n\n", commandgoPath+"/help")
+	fmt.Fprintln(buf, "func init() {")
+	fmt.Fprintln(buf, "help.HelpLibrary = append(help.HelpLibrary,")
+	for _, hs := range subjects {
+		fmt.Fprintln(buf, "&help.HelpSubject{")
+		fmt.Fprintf(buf, "Name: %q,\n", hs.Name)
+		if hs.Comment != "" {
+			fmt.Fprintf(buf, "Comment: %s,\n", quote(hs.Comment))
+		}
+		if len(hs.HelpItems) > 0 {
+			fmt.Fprintln(buf, "HelpItems: []*help.HelpItem{")
+			for _, hi := range hs.HelpItems {
+				fmt.Fprintf(buf, "{Key: %q, Comment: %s},\n", hi.Key, quote(hi.Comment))
+			}
+			fmt.Fprintln(buf, "},")
+		}
+		fmt.Fprintln(buf, "},")
+	}
+	fmt.Fprintln(buf, ")")
+	fmt.Fprintln(buf, "}")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// quote gets the given text as a Go string literal, using a raw string for multiple lines, when possible.
+func quote(s string) string {
+	if strings.Contains(s, "\n") && !strings.Contains(s, "`") {
+		return fmt.Sprintf("`%s`", s)
+	}
+	return fmt.Sprintf("%q", s)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/help"
+)
+
+func TestExtract(t *testing.T) {
+	pkg, subjects, err := Extract("testdata/app", "")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if pkg != "app" {
+		t.Fatalf("unexpected package name, %s", pkg)
+	}
+	expect := map[string]map[string]string{
+		"main": {
+			"":          "Start starts the server\nlistening on its port.",
+			"--verbose": "Verbose shows more output",
+			"--count":   "Count is the number of times to run",
+			"--name":    "Name to greet",
+			"greet":     "Greet greets the Name",
+			"server":    "Start starts the server\nlistening on its port.",
+			"remote":    "",
+		},
+		"server": {
+			"":       "Start starts the server\nlistening on its port.",
+			"--port": "Port to listen on",
+		},
+		"remote": {
+			"add": "Greet greets the Name",
+		},
+	}
+	if len(subjects) != len(expect) {
+		t.Fatalf("expected %d subjects, found %d", len(expect), len(subjects))
+	}
+	for _, hs := range subjects {
+		items, ok := expect[hs.Name]
+		if !ok {
+			t.Fatalf("unexpected subject %s", hs.Name)
+		}
+		if hs.Comment != items[""] {
+			t.Fatalf("unexpected comment of subject %s, %q", hs.Name, hs.Comment)
+		}
+		var count int
+		for k, c := range items {
+			if k != "" && c != "" {
+				count++
+			}
+		}
+		if len(hs.HelpItems) != count {
+			t.Fatalf("expected %d items in subject %s, found %d", count, hs.Name, len(hs.HelpItems))
+		}
+		for _, hi := range hs.HelpItems {
+			if c, ok := items[hi.Key]; !ok || c != hi.Comment {
+				t.Fatalf("unexpected item %s in subject %s, %q", hi.Key, hs.Name, hi.Comment)
+			}
+		}
+	}
+}
+
+func TestExtract_TypeErrors(t *testing.T) {
+	_, _, err := Extract("testdata/broken", "")
+	if err == nil {
+		t.Fatalf("expected type check error, found none")
+	}
+	for _, e := range []string{"undefined: Count", "mismatched types"} {
+		if !strings.Contains(err.Error(), e) {
+			t.Fatalf("expected error to contain %q, found %v", e, err)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	err := Generate(buf, "app", []*help.HelpSubject{{
+		Name:      "main",
+		Comment:   "the app\nruns things",
+		HelpItems: []*help.HelpItem{{Key: "--name", Comment: "Name to \"greet\""}},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "help_gen.go", buf.Bytes(), 0); err != nil {
+		t.Fatalf("generated source does not parse, %v\n%s", err, buf.String())
+	}
+	s := buf.String()
+	for _, expect := range []string{"package app", "Comment: `the app\nruns things`", `{Key: "--name", Comment: "Name to \"greet\""}`} {
+		if !strings.Contains(s, expect) {
+			t.Fatalf("expected %q in generated source, found %s", expect, s)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Command helpgen generates the help of a command line application from the Godoc comments of the functions,
+// methods, fields and variables mapped in its Commands maps.
+//
+// It parses the package in the given directory, the current directory by default, finding each commandgo.Commands
+// literal and the doc comment of what each of its keys are mapped to.
+// A Go file is written into the same package, adding a HelpSubject, for each map, to the help.HelpLibrary.
+//
+// usage: helpgen [-o help_gen.go] [directory]
+//
+// It is intended to be run by go generate, by adding the following to the package containing the Commands map:
+//
+//	//go:generate go run github.com/eurozulu/commandgo/cmd/helpgen
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	out := flag.String("o", "help_gen.go", "name of the generated file, written into the package directory")
+	flag.Parse()
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if err := generateFile(dir, *out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// generateFile extracts the help of the package in the given directory and writes it to the named file in that directory.
+func generateFile(dir, name string) error {
+	pkg, subjects, err := Extract(dir, name)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := Generate(buf, pkg, subjects); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0644)
+}
@@ -0,0 +1,54 @@
+// Package app is a sample application, mapping each kind of target, for helpgen to find the help of.
+package app
+
+import "github.com/eurozulu/commandgo"
+
+// Verbose shows more output
+var Verbose bool
+
+var (
+	// Count is the number of times to run
+	Count int
+	Name  string // Name to greet
+)
+
+// Server is a sample of mapped fields and methods.
+type Server struct {
+	// Port to listen on
+	Port int
+	Host string
+}
+
+// Start starts the server
+// listening on its port.
+func (s *Server) Start() {}
+
+// Greet greets the Name
+func Greet() {}
+
+func undocumented() {}
+
+var remote = commandgo.Commands{
+	// comments on keys are not used
+	"add":    Greet,
+	"remove": undocumented,
+}
+
+// Commands builds the commands of the app.
+func Commands() commandgo.Commands {
+	s := &Server{}
+	return commandgo.Commands{
+		"":          s.Start,
+		"--verbose": &Verbose,
+		"--count":   &Count,
+		"--name":    &Name,
+		"greet":     Greet,
+		"server": commandgo.Commands{
+			"--port": &s.Port,
+			"--host": &s.Host,
+			"":       s.Start,
+		}.Alias("--port", "-p"),
+		"remote": remote,
+		"other":  undocumented,
+	}
+}
@@ -0,0 +1,17 @@
+package broken
+
+import "github.com/eurozulu/commandgo"
+
+// Verbose shows more output
+var Verbose bool
+
+var Commands = commandgo.Commands{
+	"--verbose": &Verbose,
+	"--count":   &Count,
+	"greet":     Greet,
+}
+
+// Greet greets the name
+func Greet(name string) string {
+	return "hello " + name + 1
+}
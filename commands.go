@@ -1,13 +1,17 @@
 package commandgo
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/eurozulu/commandgo/arguments"
 	"github.com/eurozulu/commandgo/functions"
@@ -27,9 +31,22 @@ var ErrorCommandNotKnown = errors.New("command not known")
 // i.e. if the first command arg is unknown, it is treated as a parameter when invoking the default mapping
 // Assignments are only applied at each map level. i.e. top level mappings are assigned first, then any sub map assignments afterwards.
 // Only when all the assignments have been set is the final func/method mapping invoked.
+// Settings, such as ClusterFlags or Alias, are held within the map under the reserved SettingsKey.
+// Code ranging over the keys of a map must skip the SettingsKey, which is never an argument.
+// A map built by copying the entries of another shares its settings, until either is given a setting of its own.
 type Commands map[string]interface{}
 
-type flagMap map[string]*arguments.Argument
+// flagArg is a flag found in the command line, matched to the key it maps to.
+type flagArg struct {
+	key string
+	arg *arguments.Argument
+
+	// negated is true when the flag was given in its negated form, e.g. --no-verbose
+	negated bool
+}
+
+// flagArgs are the flags found in a command line, in the order they appear.
+type flagArgs []*flagArg
 
 // RunArgs executes this commands using the os.Args array as the arguments to parse.
 // Same as calling Run(os.Args[1:])
@@ -37,30 +54,95 @@ func (c Commands) RunArgs() ([]interface{}, error) {
 	return c.Run(os.Args[1:]...)
 }
 
+// RunLine executes this commands using the given command line.
+// The line is split into arguments as a shell would, using quotes and backslash escapes, before calling Run with the resulting arguments.
+// The line should not include the name of the application.
+func (c Commands) RunLine(line string) ([]interface{}, error) {
+	args, err := arguments.SplitLine(line)
+	if err != nil {
+		return nil, err
+	}
+	return c.Run(args...)
+}
+
 // Run executes this commands using the given argument array
 // All arguments mapped to assignments (variables or fields) are extracted from the given array and applied.
 // All remaining arguments are used to call a command, the first being the command and any following are used as parameters for that call.
+// Any response file arguments, e.g. @args.txt, are first replaced by the arguments contained in that file.
+// Assignments are then set from any config file (see LoadConfig) followed by those bound to environment variables,
+// before any flags are applied.
+// Functions with a leading context.Context parameter are given a background context. See RunContext.
+// The hidden commands __complete and __completion answer shell completion. See Complete.
 func (c Commands) Run(args ...string) ([]interface{}, error) {
-	// help flags are added to indicate if help requested.
-	// These prevents all other flags and commands being invoked.
-	if _, ok := c[help.HelpFlagShort]; !ok {
-		c[help.HelpFlagShort] = &help.HelpRequested
+	return c.execute(context.Background(), args)
+}
+
+// RunContext executes this commands using the given argument array, as with Run.
+// Functions with a leading context.Context parameter are given a context derived from the given context,
+// which is cancelled when the process receives an interrupt (SIGINT) or terminate (SIGTERM) signal,
+// allowing long running commands to abort cleanly.
+// Only the first signal is caught, a second terminates the process as normal, so functions without a context can still be stopped.
+func (c Commands) RunContext(ctx context.Context, args ...string) ([]interface{}, error) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// release the signals once caught, or the run is done
+		<-ctx.Done()
+		stop()
+	}()
+	return c.execute(ctx, args)
+}
+
+// execute runs this commands with the given context, once all the layers of values have been applied.
+func (c Commands) execute(ctx context.Context, args []string) ([]interface{}, error) {
+	if result, ok, err := c.runCompletion(args); ok {
+		return result, err
 	}
-	if _, ok := c[help.HelpFlagFull]; !ok {
-		c[help.HelpFlagFull] = &help.HelpRequested
+	if result, ok, err := c.runReference(args); ok {
+		return result, err
 	}
+	// The help and show config flags are global assignments, never reset by their flags.
+	// Each Run starts without them, otherwise a Run following one with --help, in the same process, would show help again.
+	help.HelpRequested = false
+	ShowConfigRequested = false
+	if _, ok := c[ShowConfigFlag]; !ok {
+		c[ShowConfigFlag] = &ShowConfigRequested
+	}
+	c.recordDefaults()
 
-	var result []interface{}
+	args, err := arguments.ExpandResponseFiles(args)
+	if err != nil {
+		return nil, err
+	}
+	if args, err = c.applyConfigFile(args); err != nil {
+		return nil, err
+	}
+	if err := c.applyEnv(""); err != nil {
+		return nil, err
+	}
+	// check the constraints of every map the command line leads to, before anything is invoked
+	if err := c.checkConstraints(args); err != nil {
+		return nil, err
+	}
+	result, err := c.run(ctx, args)
+	if err == nil && ShowConfigRequested {
+		return c.showConfig(), nil
+	}
+	return result, err
+}
 
-	// collect any flags from cmdline that are mapped in this map (removes them from args)
-	cargs := arguments.NewArguments(args)
-	flags, err := c.matchFlags(cargs)
+// run executes this commands using the given, expanded arguments.
+// Sub maps are run with the remaining arguments once this map has consumed its flags and command.
+func (c Commands) run(ctx context.Context, args []string) ([]interface{}, error) {
+	args, cargs, flags, err := c.parseFlags(args)
 	if err != nil {
 		return nil, err
 	}
 
+	var result []interface{}
+
 	// Invoke all the flags before invoking the command
-	v, err := c.invokeFlags(flags)
+	v, err := c.invokeFlags(ctx, flags)
 	if err != nil {
 		return nil, err
 	}
@@ -68,20 +150,17 @@ func (c Commands) Run(args ...string) ([]interface{}, error) {
 
 	// Establish the command key, if any
 	ca := cargs.Command() // may be empty
-	k, ok := c.findKey(ca)
-	if !ok {
-		// not known, check if default key available
-		k, ok = c.findKey("")
-	} else {
-		if ca != "" {
-			if err := cargs.Remove(&arguments.Argument{Name: ca}); err != nil {
-				return nil, err
-			}
-		}
+	k, ok, err := c.commandKey(cargs)
+	if err != nil {
+		return nil, err
 	}
 
 	if help.HelpRequested {
-		return help.ShowHelp(k, args...), nil
+		return help.ShowLibraryHelp(c.annotatedHelp(), c.HelpSubjects, k, args...), nil
+	}
+	if ShowConfigRequested && (!ok || !c.isSubmap(c[k])) {
+		// all flags have been applied, return to the top map to show the values
+		return nil, nil
 	}
 	if !ok {
 		if ca != "" {
@@ -92,7 +171,12 @@ func (c Commands) Run(args ...string) ([]interface{}, error) {
 
 	cmd := c[k]
 	// ensure all flags have been consumed if not jumping into a submap
+	var options map[reflect.Type]interface{}
 	if !c.isSubmap(cmd) {
+		// collect the flags of any options parameters of the command
+		if options, err = c.parseOptions(ctx, k, cargs); err != nil {
+			return nil, err
+		}
 		f := cargs.Flags()
 		if len(f) > 0 {
 			names := make([]string, len(f))
@@ -102,61 +186,126 @@ func (c Commands) Run(args ...string) ([]interface{}, error) {
 			return nil, fmt.Errorf("unexpected flag found, %s", strings.Join(names, ","))
 		}
 	}
-	v, err = c.invokeCommand(cmd, cargs.CommandLine())
+	params := cargs.CommandLine()
+	if !c.isSubmap(cmd) {
+		params = arguments.StripTerminator(params)
+	}
+	v, err = c.invokeCommand(ctx, k, params, options)
 	if err != nil {
 		return nil, err
 	}
 	return append(result, v...), nil
 }
 
-// invokeCommand executes the given command, using the given arguments.
+// parseFlags matches the flags, in the given arguments, mapped in this map.
+// returns the arguments, with any clusters expanded, the arguments remaining once the matched flags are removed and the matched flags.
+func (c Commands) parseFlags(args []string) ([]string, arguments.Arguments, flagArgs, error) {
+	// help flags are added to indicate if help requested.
+	// These prevents all other flags and commands being invoked.
+	if _, ok := c[help.HelpFlagShort]; !ok {
+		c[help.HelpFlagShort] = &help.HelpRequested
+	}
+	if _, ok := c[help.HelpFlagFull]; !ok {
+		c[help.HelpFlagFull] = &help.HelpRequested
+	}
+
+	if err := c.checkAliases(); err != nil {
+		return nil, nil, nil, err
+	}
+	args, err := c.expandClusters(args, c.settings().clusterFlags)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// collect any flags from cmdline that are mapped in this map (removes them from args)
+	cargs := arguments.NewArgumentsFunc(args, c.isFlag)
+	flags, err := c.matchFlags(cargs)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return args, cargs, flags, nil
+}
+
+// commandKey finds the key of the command in the given arguments, removing it from the arguments.
+// When the command is not a key, or no command is given, the default "" key is used.
+// returns false if no key is found
+func (c Commands) commandKey(cargs arguments.Arguments) (string, bool, error) {
+	ca := cargs.Command() // may be empty
+	k, ok := c.findKey(ca)
+	if !ok {
+		// not known, check if default key available
+		k, ok = c.findKey("")
+		return k, ok, nil
+	}
+	if ca != "" {
+		if err := cargs.Remove(&arguments.Argument{Name: ca}); err != nil {
+			return "", false, err
+		}
+	}
+	return k, true, nil
+}
+
+// invokeCommand executes the command mapped to the given key, using the given arguments.
+// Functions are called with the given context, when they accept one, and any given options values.
 // returns any output from the command or an error
-func (c Commands) invokeCommand(cmd interface{}, args []string) ([]interface{}, error) {
+func (c Commands) invokeCommand(ctx context.Context, key string, args []string, options map[reflect.Type]interface{}) ([]interface{}, error) {
+	cmd := c[key]
 	if c.isSubmap(cmd) {
-		return (cmd.(Commands)).Run(args...)
+		return (cmd.(Commands)).run(ctx, args)
 	}
 
 	if c.isAssignment(cmd) {
-		var a string
-		if len(args) > 0 {
-			a = args[0]
-		}
-		return nil, values.SetValue(cmd, a)
+		return nil, values.SetValue(cmd, firstParameter(args), c.validators(cmd)...)
 	}
 
 	if functions.IsFunc(cmd) {
-		return functions.CallFunc(cmd, args...)
+		fc := c.caller(ctx, key)
+		for t, v := range options {
+			fc.Inject[t] = v
+		}
+		return fc.Call(cmd, args...)
 	}
 	return nil, fmt.Errorf("command is mapped to an unknown type %T", cmd)
 }
 
+// caller gets the Caller to call the function mapped to the given key, with the given context
+func (c Commands) caller(ctx context.Context, key string) functions.Caller {
+	return functions.Caller{
+		Context:    ctx,
+		Validators: c.settings().paramValidators[key],
+		Inject:     c.injected(),
+	}
+}
+
 // invokeFlags executes the command of all the given flags.
 // Assignments (var/field pointers) are executed first, followed by any remaining func/method mappings.
-// returns any return values from the func mappings or an error
-func (c Commands) invokeFlags(flags flagMap) ([]interface{}, error) {
+// Both are executed in the order they appear in the command line.
+// returns any return values from the func mappings, in the order they were called, or an error
+func (c Commands) invokeFlags(ctx context.Context, flags flagArgs) ([]interface{}, error) {
 	// Check for help first to prevent others being invokes
-	hc := flags.HelpCommand()
-	if hc != nil {
-		return c.invokeCommand(hc, nil)
+	hk, ok := flags.HelpKey()
+	if ok {
+		return c.invokeCommand(ctx, hk, nil, nil)
 	}
 
-	funcM := map[string]*arguments.Argument{}
+	var funcFlags flagArgs
+	// assigned holds the assignments already set, so repeated flags can accumulate into slices and maps.
+	assigned := map[interface{}]bool{}
 	// perform the assignments first
-	for k, arg := range flags {
-		cmd := c[k]
-		if !c.isAssignment(cmd) {
-			funcM[k] = arg
+	for _, fa := range flags {
+		if !c.isAssignment(c[fa.key]) {
+			funcFlags = append(funcFlags, fa)
 			continue
 		}
-		_, err := c.invokeCommand(cmd, arg.Parameters)
-		if err != nil {
+		if err := c.assignFlag(fa, assigned[c[fa.key]]); err != nil {
 			return nil, err
 		}
+		assigned[c[fa.key]] = true
 	}
 	// perform any remaining flag functions,
 	var result []interface{}
-	for k, arg := range funcM {
-		iv, err := c.invokeCommand(c[k], arg.Parameters)
+	for _, fa := range funcFlags {
+		iv, err := c.invokeCommand(ctx, fa.key, fa.arg.Parameters, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -165,41 +314,109 @@ func (c Commands) invokeFlags(flags flagMap) ([]interface{}, error) {
 	return result, nil
 }
 
+// assignFlag sets the assignment of the given flag with the flags parameter and records the flag as its source.
+// repeated indicates the assignment has already been set by a previous flag, so slices and maps are added to rather than replaced.
+func (c Commands) assignFlag(fa *flagArg, repeated bool) error {
+	cmd := c[fa.key]
+	raw := firstParameter(fa.arg.Parameters)
+	var err error
+	switch {
+	case fa.negated:
+		raw = "false"
+		err = values.SetValue(cmd, raw, c.validators(cmd)...)
+	case c.isCounter(fa.key) && len(fa.arg.Parameters) == 0:
+		if err = values.Increment(cmd, c.validators(cmd)...); err == nil {
+			raw = values.FormatValue(cmd)
+		}
+	case repeated:
+		err = values.AppendValue(cmd, raw, c.validators(cmd)...)
+		if vs, ok := c.settings().sources[cmd]; ok && vs.Source == SourceFlag {
+			raw = strings.Join([]string{vs.Raw, raw}, values.SliceDelimiter)
+		}
+	default:
+		err = values.SetValue(cmd, raw, c.validators(cmd)...)
+	}
+	if err != nil {
+		return err
+	}
+	c.recordSource(fa.key, SourceFlag, raw, fa.arg.Name)
+	return nil
+}
+
 // matches any flags found in the given arguments, with mapped flags in this Commands.
 // Any matched arguments are removed from the given args and copied to the resulting map.
-// returns a map keyed with the 'real' (not the command line arg) keys of this commands, mapping to the matching Argument
-func (c Commands) matchFlags(args arguments.Arguments) (flagMap, error) {
-	m := flagMap{}
+// returns the matched flags, in command line order, with the 'real' (not the command line arg) keys of this commands
+func (c Commands) matchFlags(args arguments.Arguments) (flagArgs, error) {
+	var m flagArgs
 	flags := args.Flags()
 	for _, arg := range flags {
 		k, ok := c.findKey(arg.Name)
 		if !ok {
-			continue
-		}
-		var err error
-		arg.Parameters, err = c.trimParameters(c[k], arg.Parameters)
-		if err != nil {
-			return nil, err
+			if k, ok = c.findNegatedKey(arg.Name); !ok {
+				continue
+			}
+			if arg.Inline {
+				return nil, fmt.Errorf("flag %s does not take a value", arg.Name)
+			}
+			arg.Parameters = nil
+			m = append(m, &flagArg{key: k, arg: arg, negated: true})
+		} else {
+			var err error
+			arg.Parameters, err = c.trimParameters(k, arg)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, &flagArg{key: k, arg: arg})
 		}
-		m[k] = arg
 		if err := args.Remove(arg); err != nil {
 			log.Fatalln(err)
 		}
 	}
+	if err := c.checkNegations(m); err != nil {
+		return nil, err
+	}
 	return m, nil
 }
 
 // findKey finds a key from an argumenet in a case insensitive search
+// An exact match is preferred, otherwise, when more than one key matches, the first in sorted order is used.
 func (c Commands) findKey(arg string) (string, bool) {
-	for k := range c {
+	if _, ok := c[arg]; ok {
+		return arg, true
+	}
+	if k, ok := c.settings().aliases[arg]; ok {
+		return k, true
+	}
+	var found []string
+	for _, k := range c.sortedKeys() {
 		if strings.EqualFold(k, arg) {
-			return k, true
+			found = append(found, k)
 		}
 	}
-	return "", false
+	if len(found) == 0 {
+		return c.findAlias(arg)
+	}
+	sort.Strings(found)
+	return found[0], true
 }
 
-func (c Commands) trimParameters(cmd interface{}, parameters []string) ([]string, error) {
+// sortedKeys gets all the keys of this map, in sorted order, less the key holding its settings.
+func (c Commands) sortedKeys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		if k == SettingsKey {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// trimParameters trims the parameters of the given flag argument to those required by the command its key maps to.
+func (c Commands) trimParameters(key string, arg *arguments.Argument) ([]string, error) {
+	cmd := c[key]
+	parameters := arg.Parameters
 	if !c.isAssignment(cmd) {
 		return parameters, nil
 	}
@@ -208,11 +425,27 @@ func (c Commands) trimParameters(cmd interface{}, parameters []string) ([]string
 		parameters = parameters[0:1]
 	}
 
+	// negative numbers are only values of numeric assignments, otherwise they are taken to be a flag
+	if len(parameters) > 0 && !arg.Inline && arguments.IsNumber(parameters[0]) && !values.IsNumeric(reflect.TypeOf(cmd)) {
+		parameters = parameters[:0]
+	}
+
+	// counters only have a parameter when given inline
+	if c.isCounter(key) {
+		if !arg.Inline {
+			parameters = parameters[:0]
+		}
+		return parameters, nil
+	}
+
 	// Special case for bools, which have optional parameter
 	if values.IsKind(cmd, reflect.Bool) {
 		// see if following parameter is, in fact a bool otherwise don't use it.
 		if len(parameters) > 0 {
 			if _, err := strconv.ParseBool(parameters[0]); err != nil {
+				if arg.Inline {
+					return nil, fmt.Errorf("flag %s value %s could not be read as a bool", arg.Name, parameters[0])
+				}
 				parameters = parameters[:0]
 			}
 		}
@@ -225,7 +458,27 @@ func (c Commands) trimParameters(cmd interface{}, parameters []string) ([]string
 
 }
 
+// isFlag checks if the given argument is a flag.
+// Negative numbers are values, unless mapped as a key in this map, or any of its sub maps.
+func (c Commands) isFlag(arg string) bool {
+	if arguments.IsFlag(arg) {
+		return true
+	}
+	return arguments.IsNumber(arg) && c.treeHasKey(arg)
+}
+
+// firstParameter gets the first of the given parameters or an empty string if there are none
+func firstParameter(params []string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	return params[0]
+}
+
 func (c Commands) isAssignment(cmd interface{}) bool {
+	if _, ok := cmd.(*settings); ok {
+		return false
+	}
 	return reflect.TypeOf(cmd).Kind() == reflect.Ptr && !functions.IsFunc(cmd)
 }
 
@@ -234,14 +487,22 @@ func (c Commands) isSubmap(cmd interface{}) bool {
 	return ok
 }
 
-func (m flagMap) HelpCommand() interface{} {
-	cmd, ok := m[help.HelpFlagShort]
-	if ok {
-		return cmd
+// hasKey checks if the given key is in the flags
+func (m flagArgs) hasKey(key string) bool {
+	for _, fa := range m {
+		if fa.key == key {
+			return true
+		}
 	}
-	cmd, ok = m[help.HelpFlagFull]
-	if ok {
-		return cmd
+	return false
+}
+
+// HelpKey gets the key of the first help flag found in the flags
+func (m flagArgs) HelpKey() (string, bool) {
+	for _, fa := range m {
+		if fa.key == help.HelpFlagShort || fa.key == help.HelpFlagFull {
+			return fa.key, true
+		}
 	}
-	return nil
+	return "", false
 }
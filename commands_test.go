@@ -1,11 +1,29 @@
 package commandgo
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/eurozulu/commandgo/completion"
+	"github.com/eurozulu/commandgo/config"
+	"github.com/eurozulu/commandgo/functions"
+	"github.com/eurozulu/commandgo/help"
+	"github.com/eurozulu/commandgo/values"
 )
 
 var testVarBool bool
@@ -311,3 +329,1452 @@ func TestCommands_Run_Submaps(t *testing.T) {
 	}
 
 }
+
+func TestCommands_Run_InlineValues(t *testing.T) {
+	testVarBool = false
+	testVarString = ""
+	test := &testStruct{}
+	cmds := Commands{
+		"--flag1":        &testVarBool,
+		"--content-type": &testVarString,
+		"-i":             &test.FieldInt,
+		"":               testFunc,
+	}
+
+	out, err := cmds.Run("--content-type=application/json", "-i=-5", "--flag1=false", "teststring")
+	if err != nil {
+		t.Fatalf("unexpected error with inline flag values, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "--teststring--" {
+		t.Fatalf("unexpected output expected %v, found %v", "--teststring--", out)
+	}
+	if testVarString != "application/json" {
+		t.Fatalf("unexpected testvarstring, expected %v, found %v", "application/json", testVarString)
+	}
+	if test.FieldInt != -5 {
+		t.Fatalf("unexpected field value. int expected %v, found %v", -5, test.FieldInt)
+	}
+	if testVarBool {
+		t.Fatalf("unexpected testvarbool, expected %v, found %v", false, testVarBool)
+	}
+
+	_, err = cmds.Run("teststring", "--content-type=a=b", "--flag1")
+	if err != nil {
+		t.Fatalf("unexpected error with inline flag values, %v", err)
+	}
+	if testVarString != "a=b" {
+		t.Fatalf("unexpected testvarstring, expected %v, found %v", "a=b", testVarString)
+	}
+	if !testVarBool {
+		t.Fatalf("unexpected testvarbool, expected %v, found %v", true, testVarBool)
+	}
+
+	_, err = cmds.Run("teststring", "--flag1=hello")
+	if err == nil {
+		t.Fatalf("expected error with non bool inline value on bool flag")
+	}
+}
+
+func TestCommands_Run_ClusterFlags(t *testing.T) {
+	test := &testStruct{}
+	cmds := Commands{
+		"-b":  &test.FieldBool,
+		"-i":  &test.FieldInt,
+		"-ct": &testVarString,
+		"":    testFunc,
+		"sub": Commands{
+			"-u": &testVarURL,
+			"":   testFunc,
+		},
+	}
+
+	// clustering disabled by default
+	_, err := cmds.Run("-bi5", "teststring")
+	if err == nil || !strings.HasPrefix(err.Error(), "unexpected flag found") {
+		t.Fatalf("expected error unexpected flag found, %v", err)
+	}
+
+	cmds.ClusterFlags(true)
+	_, err = cmds.Run("-bi5", "teststring")
+	if err != nil {
+		t.Fatalf("unexpected error with clustered flags, %v", err)
+	}
+	if !test.FieldBool {
+		t.Fatalf("unexpected field value. bool expected %v, found %v", true, test.FieldBool)
+	}
+	if test.FieldInt != 5 {
+		t.Fatalf("unexpected field value. int expected %v, found %v", 5, test.FieldInt)
+	}
+
+	testVarURL = nil
+	test.FieldBool = false
+	_, err = cmds.Run("sub", "teststring", "-bu", "http://www.google.com")
+	if err != nil {
+		t.Fatalf("unexpected error with clustered flags in sub map, %v", err)
+	}
+	if !test.FieldBool || testVarURL == nil {
+		t.Fatalf("unexpected values with clustered flags in sub map, %v, %v", test.FieldBool, testVarURL)
+	}
+
+	// multi letter key still matched as a whole
+	_, err = cmds.Run("-ct", "hello", "teststring")
+	if err != nil {
+		t.Fatalf("unexpected error with multi letter key, %v", err)
+	}
+	if testVarString != "hello" {
+		t.Fatalf("unexpected testvarstring, expected %v, found %v", "hello", testVarString)
+	}
+
+	// ambiguous when cluster also matches
+	cmds["-c"] = &test.FieldBool
+	cmds["-t"] = &test.FieldBool
+	_, err = cmds.Run("-ct", "hello", "teststring")
+	if err == nil || !strings.HasPrefix(err.Error(), "ambiguous flag") {
+		t.Fatalf("expected ambiguous flag error, found %v", err)
+	}
+}
+
+func TestCommands_Settings(t *testing.T) {
+	// settings of separate maps are held by each map, so can be attached concurrently
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var count int
+			name := fmt.Sprintf("cmd%d", i)
+			cmds := Commands{
+				"-v": &count,
+				name: testFunc,
+			}.Counter("-v").Alias(name, "alias")
+			cmds.ClusterFlags(true)
+			if !cmds.isCounter("-v") || !cmds.settings().clusterFlags {
+				errs <- fmt.Errorf("settings of %s not found", name)
+			}
+			if found := strings.Join(cmds.Complete(""), "|"); found != fmt.Sprintf("alias|%s|:1", name) {
+				errs <- fmt.Errorf("unexpected commands of %s, %s", name, found)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+
+	cmds := Commands{"-v": &testVarBool}.ClusterFlags(true)
+	if keys := cmds.sortedKeys(); len(keys) != 1 || keys[0] != "-v" {
+		t.Fatalf("expected settings to be excluded from keys, found %q", keys)
+	}
+	merged := Commands{"-a": &testVarString}.ClusterFlags(false).Merge(cmds)
+	if !merged.settings().clusterFlags || merged.settings() == cmds.settings() {
+		t.Fatalf("expected settings to be merged into the settings of the map")
+	}
+	if cmds.isAssignment(cmds[SettingsKey]) {
+		t.Fatalf("expected settings not to be an assignment")
+	}
+
+	// a map copied from another shares its settings until given its own
+	var count int
+	orig := Commands{"-c": &count, "--verbose": &testVarBool}.Alias("--verbose", "-V")
+	cp := Commands{}
+	for k, v := range orig {
+		cp[k] = v
+	}
+	if k, ok := cp.findKey("-V"); !ok || k != "--verbose" {
+		t.Fatalf("expected alias copied with the map")
+	}
+	cp.Counter("-c").Alias("--verbose", "--loud")
+	if orig.isCounter("-c") || len(orig.Aliases("--verbose")) != 1 {
+		t.Fatalf("expected settings of the copy not to change the original, found %v", orig.Aliases("--verbose"))
+	}
+	if !cp.isCounter("-c") || len(cp.Aliases("--verbose")) != 2 {
+		t.Fatalf("expected settings of the copy to be changed, found %v", cp.Aliases("--verbose"))
+	}
+}
+
+func TestCommands_Run_HelpNotRepeated(t *testing.T) {
+	cmds := Commands{
+		"echo": testFunc,
+	}
+	if _, err := cmds.Run("echo", "--help"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	out, err := cmds.Run("echo", "hello")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "--hello--" {
+		t.Fatalf("expected the command to run after a help request, found %v", out)
+	}
+}
+
+func TestCommands_Run_Terminator(t *testing.T) {
+	testVarBool = false
+	test := &testStruct{}
+	cmds := Commands{
+		"-b":   &testVarBool,
+		"-i":   &test.FieldInt,
+		"num":  testFuncInt,
+		"echo": testFunc,
+		"sub": Commands{
+			"": testFunc,
+		},
+	}
+
+	out, err := cmds.Run("echo", "-b", "--", "-b")
+	if err != nil {
+		t.Fatalf("unexpected error with terminator, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "---b--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "---b--", out)
+	}
+	if !testVarBool {
+		t.Fatalf("unexpected testvarbool, expected %v, found %v", true, testVarBool)
+	}
+
+	out, err = cmds.Run("sub", "--", "-pattern")
+	if err != nil {
+		t.Fatalf("unexpected error with terminator in sub map, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "---pattern--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "---pattern--", out)
+	}
+
+	out, err = cmds.Run("num", "-5", "-i", "-1")
+	if err != nil {
+		t.Fatalf("unexpected error with negative numbers, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "---5--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "---5--", out)
+	}
+	if test.FieldInt != -1 {
+		t.Fatalf("unexpected field value. int expected %v, found %v", -1, test.FieldInt)
+	}
+
+	// a negative number is a flag when mapped as a key
+	var five bool
+	cmds["-5"] = &five
+	out, err = cmds.Run("num", "-1", "-5")
+	if err != nil {
+		t.Fatalf("unexpected error with negative number key, %v", err)
+	}
+	if !five {
+		t.Fatalf("expected -5 to be a flag")
+	}
+	if len(out) != 1 || out[0].(string) != "---1--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "---1--", out)
+	}
+	delete(cmds, "-5")
+
+	// a negative number is a flag when the destination is not numeric
+	cmds["--name"] = &testVarString
+	if _, err = cmds.Run("num", "--name", "-3"); err == nil || err.Error() != "no parameter value found for flag" {
+		t.Fatalf("expected no parameter error for non numeric flag, found %v", err)
+	}
+}
+
+func TestCommands_Run_FlagOrder(t *testing.T) {
+	var order []string
+	cmds := Commands{
+		"-a": func() string {
+			order = append(order, "a")
+			return "a"
+		},
+		"-b": func() string {
+			order = append(order, "b")
+			return "b"
+		},
+		"-c": func() string {
+			order = append(order, "c")
+			return "c"
+		},
+		"-s": &testVarString,
+		"":   testFunc,
+	}
+	for i := 0; i < 20; i++ {
+		order = nil
+		out, err := cmds.Run("teststring", "-c", "-a", "-b", "-s", "hello")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if strings.Join(order, "") != "cab" {
+			t.Fatalf("unexpected flag order. expected %s, found %s", "cab", strings.Join(order, ""))
+		}
+		if fmt.Sprint(out) != "[[c] [a] [b] --teststring--]" {
+			t.Fatalf("unexpected output. expected %s, found %v", "[[c] [a] [b] --teststring--]", out)
+		}
+	}
+
+	cmds = Commands{
+		"-S": &testVarBool,
+		"-s": &testVarString,
+		"":   testFunc,
+	}
+	testVarBool = false
+	if _, err := cmds.Run("-s", "hello", "teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if testVarBool || testVarString != "hello" {
+		t.Fatalf("unexpected key matched for exact case flag")
+	}
+}
+
+func TestCommands_Run_RepeatedFlags(t *testing.T) {
+	var headers []string
+	var labels map[string]string
+	cmds := Commands{
+		"-H":       &headers,
+		"--header": &headers,
+		"-l":       &labels,
+		"":         testFunc,
+	}
+
+	headers = []string{"default"}
+	if _, err := cmds.Run("teststring", "-H", "a", "--header", "b"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if strings.Join(headers, " ") != "a b" {
+		t.Fatalf("unexpected slice value. expected %v, found %v", "[a b]", headers)
+	}
+
+	if _, err := cmds.Run("teststring", "-H", "a,b"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if strings.Join(headers, " ") != "a b" {
+		t.Fatalf("unexpected slice value. expected %v, found %v", "[a b]", headers)
+	}
+
+	if _, err := cmds.Run("teststring", "-H", "a,b", "-H=c"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if strings.Join(headers, " ") != "a b c" {
+		t.Fatalf("unexpected slice value. expected %v, found %v", "[a b c]", headers)
+	}
+
+	if _, err := cmds.Run("teststring", "-l", `{"a": "1", "b": "2"}`, "-l", `{"b": "3", "c": "4"}`); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if fmt.Sprint(labels) != "map[a:1 b:3 c:4]" {
+		t.Fatalf("unexpected map value. expected %v, found %v", "map[a:1 b:3 c:4]", labels)
+	}
+}
+
+func TestCommands_Run_Counter(t *testing.T) {
+	var verbosity int
+	test := &testStruct{}
+	cmds := Commands{
+		"-v":        &verbosity,
+		"--verbose": &verbosity,
+		"-i":        &test.FieldInt,
+		"":          testFunc,
+	}.Counter("-v", "--verbose")
+
+	if _, err := cmds.Run("-v", "teststring", "-v", "--verbose"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if verbosity != 3 {
+		t.Fatalf("unexpected counter value. expected %d, found %d", 3, verbosity)
+	}
+
+	verbosity = 0
+	if _, err := cmds.Run("-vvv", "teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if verbosity != 3 {
+		t.Fatalf("unexpected counter value. expected %d, found %d", 3, verbosity)
+	}
+
+	if _, err := cmds.Run("-v=5", "teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if verbosity != 5 {
+		t.Fatalf("unexpected counter value. expected %d, found %d", 5, verbosity)
+	}
+
+	// ordinary int flags still require a value
+	if _, err := cmds.Run("teststring", "-i"); err == nil {
+		t.Fatalf("expected error with int flag missing its value")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic declaring non int counter")
+		}
+	}()
+	Commands{"-b": &testVarBool}.Counter("-b")
+}
+
+func TestCommands_Run_Negation(t *testing.T) {
+	cmds := Commands{
+		"--verbose": &testVarBool,
+		"-s":        &testVarString,
+		"":          testFunc,
+	}
+	testVarBool = true
+	if _, err := cmds.Run("teststring", "--no-verbose"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if testVarBool {
+		t.Fatalf("unexpected testvarbool, expected %v, found %v", false, testVarBool)
+	}
+
+	_, err := cmds.Run("teststring", "--verbose", "--no-verbose")
+	if err == nil || !strings.HasPrefix(err.Error(), "conflicting flags") {
+		t.Fatalf("expected conflicting flags error, found %v", err)
+	}
+
+	_, err = cmds.Run("teststring", "--no-s", "hello")
+	if err == nil || !strings.HasPrefix(err.Error(), "unexpected flag found") {
+		t.Fatalf("expected unexpected flag error negating non bool flag, found %v", err)
+	}
+
+	help.HelpLibrary = []*help.HelpSubject{{
+		Name:      "main",
+		HelpItems: []*help.HelpItem{{Key: "--verbose", Comment: "shows more"}},
+	}}
+	defer func() { help.HelpLibrary = nil }()
+	out, err := cmds.Run("--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "--verbose, --no-verbose") {
+		t.Fatalf("expected negated flag in help, found %v", out)
+	}
+
+	// only the subject of the map is annotated, in a copy of the library
+	help.HelpLibrary = append(help.HelpLibrary, &help.HelpSubject{
+		Name:      "other",
+		HelpItems: []*help.HelpItem{{Key: "--verbose", Comment: "shows more"}},
+	})
+	lib := cmds.annotatedHelp()
+	if hi := lib[0].Item("--verbose"); hi.Negation != "--no-verbose" {
+		t.Fatalf("expected negation in annotated help, found %q", hi.Negation)
+	}
+	for _, hs := range append(help.HelpLibrary, lib[1]) {
+		if hi := hs.Item("--verbose"); hi.Negation != "" {
+			t.Fatalf("expected %s subject to be unchanged, found negation %q", hs.Name, hi.Negation)
+		}
+	}
+}
+
+func TestCommands_Run_ResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		fn := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write test file %v", err)
+		}
+		return fn
+	}
+	args := writeFile("args.txt", "# test arguments\n-s 'hello world' \\\n  @nested.txt\n")
+	writeFile("nested.txt", `-b "true"`)
+
+	testVarBool = false
+	testVarString = ""
+	cmds := Commands{
+		"-b": &testVarBool,
+		"-s": &testVarString,
+		"":   testFunc,
+	}
+	out, err := cmds.Run("@"+args, "@@teststring")
+	if err != nil {
+		t.Fatalf("unexpected error with response file, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "--@teststring--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "--@teststring--", out)
+	}
+	if testVarString != "hello world" {
+		t.Fatalf("unexpected testvarstring, expected %v, found %v", "hello world", testVarString)
+	}
+	if !testVarBool {
+		t.Fatalf("unexpected testvarbool, expected %v, found %v", true, testVarBool)
+	}
+
+	cycle := writeFile("cycle.txt", "-b @cycle.txt")
+	_, err = cmds.Run("@" + cycle)
+	if err == nil || !strings.Contains(err.Error(), "includes itself") {
+		t.Fatalf("expected response file cycle error, found %v", err)
+	}
+
+	_, err = cmds.Run("@" + filepath.Join(dir, "missing.txt"))
+	if err == nil {
+		t.Fatalf("expected error with missing response file")
+	}
+
+	unterminated := writeFile("unterminated.txt", `-s "hello`)
+	_, err = cmds.Run("@" + unterminated)
+	if err == nil || !strings.Contains(err.Error(), "unterminated") {
+		t.Fatalf("expected unterminated quote error, found %v", err)
+	}
+}
+
+func TestCommands_RunLine(t *testing.T) {
+	testVarString = ""
+	cmds := Commands{
+		"-s": &testVarString,
+		"":   testFunc,
+	}
+	out, err := cmds.RunLine(`"test string" -s 'hello world'`)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || out[0].(string) != "--test string--" {
+		t.Fatalf("unexpected output.  expected %v, found %v", "--test string--", out)
+	}
+	if testVarString != "hello world" {
+		t.Fatalf("unexpected testvarstring, expected %v, found %v", "hello world", testVarString)
+	}
+
+	_, err = cmds.RunLine(`teststring -s "hello`)
+	if err == nil || !strings.HasPrefix(err.Error(), "unterminated") {
+		t.Fatalf("expected unterminated quote error, found %v", err)
+	}
+}
+
+func TestCommands_Run_Env(t *testing.T) {
+	test := &testStruct{}
+	cmds := Commands{
+		"--verbose": &testVarBool,
+		"-i":        &test.FieldInt,
+		"":          testFunc,
+		"sub": Commands{
+			"--content-type": &testVarString,
+			"":               testFunc,
+		},
+	}.EnvPrefix("testapp").Env("-i", "TEST_INT")
+
+	os.Setenv("TESTAPP_VERBOSE", "true")
+	os.Setenv("TESTAPP_CONTENT_TYPE", "text/plain")
+	os.Setenv("TEST_INT", "42")
+	defer func() {
+		os.Unsetenv("TESTAPP_VERBOSE")
+		os.Unsetenv("TESTAPP_CONTENT_TYPE")
+		os.Unsetenv("TEST_INT")
+	}()
+
+	testVarBool = false
+	testVarString = ""
+	if _, err := cmds.Run("sub", "teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !testVarBool || testVarString != "text/plain" || test.FieldInt != 42 {
+		t.Fatalf("unexpected values set from environment, %v, %v, %v", testVarBool, testVarString, test.FieldInt)
+	}
+
+	// flags override environment
+	if _, err := cmds.Run("sub", "teststring", "--content-type", "application/json", "-i", "1"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if testVarString != "application/json" || test.FieldInt != 1 {
+		t.Fatalf("unexpected values overriding environment, %v, %v", testVarString, test.FieldInt)
+	}
+
+	os.Setenv("TEST_INT", "nonsense")
+	_, err := cmds.Run("teststring")
+	if err == nil || !strings.Contains(err.Error(), "TEST_INT") {
+		t.Fatalf("expected error naming environment variable, found %v", err)
+	}
+	os.Setenv("TEST_INT", "42")
+
+	help.HelpLibrary = []*help.HelpSubject{{
+		Name:      "sub",
+		HelpItems: []*help.HelpItem{{Key: "--content-type", Comment: "the content type"}},
+	}}
+	defer func() { help.HelpLibrary = nil }()
+	out, err := cmds.Run("sub", "--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "TESTAPP_CONTENT_TYPE") {
+		t.Fatalf("expected environment variable in help, found %v", out)
+	}
+}
+
+func TestCommands_Run_Config(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "test.yaml")
+	content := "verbose: true\nsub:\n  content-type: text/plain\n  int: 42\n"
+	if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file %v", err)
+	}
+	test := &testStruct{}
+	cmds := Commands{
+		"--verbose": &testVarBool,
+		"--config":  &config.ConfigFile,
+		"":          testFunc,
+		"sub": Commands{
+			"--content-type": &testVarString,
+			"--int":          &test.FieldInt,
+			"":               testFunc,
+		},
+	}
+	defer func() { config.ConfigFile = "" }()
+
+	testVarBool = false
+	testVarString = ""
+	if _, err := cmds.Run("sub", "teststring", "--config", fn, "--int", "1"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !testVarBool || testVarString != "text/plain" {
+		t.Fatalf("unexpected values set from config, %v, %v", testVarBool, testVarString)
+	}
+	if test.FieldInt != 1 {
+		t.Fatalf("unexpected value, flag should override config. expected %d, found %d", 1, test.FieldInt)
+	}
+
+	// missing default file is ignored, missing flagged file is not
+	config.ConfigFile = filepath.Join(dir, "missing.yaml")
+	if _, err := cmds.Run("teststring"); err != nil {
+		t.Fatalf("unexpected error with missing default config file, %v", err)
+	}
+	if _, err := cmds.Run("teststring", "--config", config.ConfigFile); err == nil {
+		t.Fatalf("expected error with missing config file")
+	}
+
+	content = "verbose: true\nsub:\n  int: nonsense\n"
+	if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write test file %v", err)
+	}
+	err := cmds.LoadConfig(fn)
+	if err == nil || !strings.HasPrefix(err.Error(), fn+":3: sub.int") {
+		t.Fatalf("expected error naming file, line and key, found %v", err)
+	}
+}
+
+func TestCommands_Run_ValueSources(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "test.ini")
+	if err := ioutil.WriteFile(fn, []byte("[sub]\ncontent-type = text/plain\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file %v", err)
+	}
+	testVarBool = false
+	test := &testStruct{FieldInt: 30}
+	cmds := Commands{
+		"--verbose": &testVarBool,
+		"--int":     &test.FieldInt,
+		"-i":        &test.FieldInt,
+		"--config":  &config.ConfigFile,
+		"":          testFunc,
+		"sub": Commands{
+			"--content-type": &testVarString,
+			"--float":        &test.FieldFloat,
+			"":               testFunc,
+		},
+	}.Env("--verbose", "TEST_VERBOSE")
+	os.Setenv("TEST_VERBOSE", "true")
+	defer os.Unsetenv("TEST_VERBOSE")
+	defer func() { config.ConfigFile = "" }()
+
+	if _, err := cmds.Run("sub", "teststring", "--config", fn, "--float", "0.5"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	expect := map[string]string{
+		"--int":              "--int = 30\t(default)",
+		"--verbose":          "--verbose = true\t(env TEST_VERBOSE)",
+		"sub --content-type": "sub --content-type = text/plain\t(config " + fn + ":2)",
+		"sub --float":        "sub --float = 0.5\t(flag --float)",
+	}
+	var matched int
+	for _, vs := range cmds.ValueSources() {
+		e, ok := expect[vs.Key]
+		if !ok {
+			continue
+		}
+		if e != vs.String() {
+			t.Fatalf("unexpected value source, expected %q, found %q", e, vs.String())
+		}
+		matched++
+	}
+	if matched != len(expect) {
+		t.Fatalf("unexpected value sources, expected %d, found %d", len(expect), matched)
+	}
+	vs, ok := cmds.ValueSource("sub", "--float")
+	if !ok || vs.Source != SourceFlag || vs.Raw != "0.5" {
+		t.Fatalf("unexpected value source for sub --float, %v", vs)
+	}
+
+	out, err := cmds.Run("sub", "teststring", "-i", "5", "--show-config")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	var found bool
+	for _, l := range out {
+		if l.(string) == "-i = 5\t(flag -i)" {
+			found = true
+		}
+		if strings.HasPrefix(l.(string), ShowConfigFlag) {
+			t.Fatalf("unexpected show config flag in shown config, %v", out)
+		}
+	}
+	if !found {
+		t.Fatalf("expected -i flag in shown config, found %v", out)
+	}
+	if cmds.isNegatable(ShowConfigFlag) || cmds.envName(ShowConfigFlag, "testapp") != "" {
+		t.Fatalf("expected show config flag to have no negation or environment variable")
+	}
+
+	// values of the previous Run are kept, as the defaults of the next
+	os.Unsetenv("TEST_VERBOSE")
+	config.ConfigFile = ""
+	testVarString = "changed"
+	if _, err := cmds.Run("teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if test.FieldInt != 5 || test.FieldFloat != 0.5 || !testVarBool {
+		t.Fatalf("expected values of the previous run to be kept, found %v, %v, %v", test.FieldInt, test.FieldFloat, testVarBool)
+	}
+	expect = map[string]string{
+		"--int":              "--int = 5\t(default)",
+		"--verbose":          "--verbose = true\t(default)",
+		"sub --content-type": "sub --content-type = changed\t(default)",
+		"sub --float":        "sub --float = 0.5\t(default)",
+	}
+	for _, vs := range cmds.ValueSources() {
+		if e, ok := expect[vs.Key]; ok && e != vs.String() {
+			t.Fatalf("unexpected value source, expected %q, found %q", e, vs.String())
+		}
+	}
+}
+
+func TestCommands_Run_Constraints(t *testing.T) {
+	var contentType, user, password string
+	var json, table bool
+	cmds := Commands{
+		"--content-type": &contentType,
+		"-ct":            &contentType,
+		"--json":         &json,
+		"--table":        &table,
+		"--user":         &user,
+		"--password":     &password,
+		"":               testFunc,
+	}.Require("--content-type").AtMostOneOf("--json", "--table").AllOrNone("--user", "--password")
+
+	if _, err := cmds.Run("teststring", "-ct", "text/plain", "--json", "--no-table"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	_, err := cmds.Run("teststring", "--json", "--table", "--user", "me")
+	if err == nil {
+		t.Fatalf("expected constraint error, found none")
+	}
+	ce, ok := err.(*ConstraintError)
+	if !ok {
+		t.Fatalf("expected ConstraintError, found %T", err)
+	}
+	if len(ce.Violations) != 3 {
+		t.Fatalf("expected 3 violations, found %d, %v", len(ce.Violations), ce.Violations)
+	}
+
+	os.Setenv("TEST_CONTENT_TYPE", "text/plain")
+	defer os.Unsetenv("TEST_CONTENT_TYPE")
+	cmds.Env("--content-type", "TEST_CONTENT_TYPE")
+	if _, err := cmds.Run("teststring"); err != nil {
+		t.Fatalf("unexpected error with required flag set from env, %v", err)
+	}
+
+	excl := Commands{
+		"--json":  &json,
+		"--table": &table,
+		"":        testFunc,
+	}.ExactlyOneOf("--json", "--table")
+	if _, err := excl.Run("teststring"); err == nil {
+		t.Fatalf("expected error with neither of exactly one group, found none")
+	}
+	if _, err := excl.Run("teststring", "--table"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+}
+
+func TestCommands_Run_ConstraintsSubMap(t *testing.T) {
+	var contentType, user string
+	var verbose bool
+	cmds := Commands{
+		"--verbose": func() { verbose = true },
+		"--user":    &user,
+		"post": Commands{
+			"--content-type": &contentType,
+			"":               testFunc,
+		}.Require("--content-type"),
+	}.Require("--user")
+
+	_, err := cmds.Run("post", "teststring", "--verbose")
+	ce, ok := err.(*ConstraintError)
+	if !ok {
+		t.Fatalf("expected ConstraintError, found %v", err)
+	}
+	if len(ce.Violations) != 2 {
+		t.Fatalf("expected 2 violations, found %d, %v", len(ce.Violations), ce.Violations)
+	}
+	if verbose {
+		t.Fatalf("expected parent flag not to be invoked when sub map constraint broken")
+	}
+	if _, err := cmds.Run("--user", "me", "post", "teststring", "--content-type", "text/plain", "--verbose"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !verbose {
+		t.Fatalf("expected parent flag to be invoked")
+	}
+}
+
+func TestCommands_Run_Validate(t *testing.T) {
+	var perm os.FileMode
+	var method string
+	var count int
+	lower := func(v interface{}) error {
+		if s := v.(string); s != strings.ToLower(s) {
+			return fmt.Errorf("%s is not lower case", s)
+		}
+		return nil
+	}
+	even := func(v interface{}) error {
+		if v.(int)%2 != 0 {
+			return fmt.Errorf("%d is not even", v)
+		}
+		return nil
+	}
+	cmds := Commands{
+		"--permissions": &perm,
+		"-p":            &perm,
+		"--method":      &method,
+		"--count":       &count,
+		"":              testFuncInt,
+	}.Counter("--count").
+		Validate("--permissions", values.Range(0, 0777)).
+		Validate("--method", lower, values.OneOf("get", "post")).
+		Validate("--count", even).
+		ValidateParam("", 0, even)
+
+	if _, err := cmds.Run("2", "-p", "420", "--method", "get"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if perm != 0644 {
+		t.Fatalf("unexpected permissions, expected %o, found %o", 0644, perm)
+	}
+	if _, err := cmds.Run("2", "-p", "99999"); err == nil {
+		t.Fatalf("expected error with out of range alias, found none")
+	}
+	if _, err := cmds.Run("2", "--method", "delete"); err == nil {
+		t.Fatalf("expected error with invalid choice, found none")
+	}
+	if _, err := cmds.Run("2", "--method", "GET"); err == nil || err.Error() != "GET is not lower case" {
+		t.Fatalf("expected custom validator error, found %v", err)
+	}
+	if _, err := cmds.Run("2", "--count", "--count"); err == nil {
+		t.Fatalf("expected error with odd counter, found none")
+	}
+	if _, err := cmds.Run("3"); err == nil {
+		t.Fatalf("expected error with invalid parameter, found none")
+	}
+}
+
+type testColour int
+
+func TestCommands_Run_Enum(t *testing.T) {
+	ct := reflect.TypeOf(testColour(0))
+	values.NewEnum(ct, map[string]interface{}{"red": 1, "green": 2, "blue": 3})
+	defer values.NewEnum(ct, nil)
+
+	var colour testColour
+	cmds := Commands{
+		"--colour": &colour,
+		"":         testFunc,
+	}
+	if _, err := cmds.Run("teststring", "--colour", "GR"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if colour != 2 {
+		t.Fatalf("unexpected colour, expected 2, found %d", colour)
+	}
+
+	help.HelpLibrary = []*help.HelpSubject{{
+		Name:      "main",
+		HelpItems: []*help.HelpItem{{Key: "--colour", Comment: "the colour"}},
+	}}
+	defer func() { help.HelpLibrary = nil }()
+	out, err := cmds.Run("--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "--colour {blue|green|red}") {
+		t.Fatalf("expected choices in help, found %v", out)
+	}
+}
+
+type testContextKey string
+
+func TestCommands_RunContext(t *testing.T) {
+	var found string
+	cmds := Commands{
+		"value": func(ctx context.Context, s string) string {
+			found = s
+			v, _ := ctx.Value(testContextKey("key")).(string)
+			return v
+		},
+		"wait": func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+	ctx := context.WithValue(context.Background(), testContextKey("key"), "testvalue")
+	out, err := cmds.RunContext(ctx, "value", "teststring")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if found != "teststring" || len(out) != 1 || out[0] != "testvalue" {
+		t.Fatalf("unexpected result calling with context, found %q, %v", found, out)
+	}
+
+	// without a context, a background context is given
+	if _, err := cmds.Run("value", "teststring"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if _, err := cmds.Run("value"); err == nil {
+		t.Fatalf("expected missing argument error, found none")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cmds.RunContext(ctx, "wait"); err != context.Canceled {
+		t.Fatalf("expected cancelled error, found %v", err)
+	}
+}
+
+func TestCommands_RunContext_Signal(t *testing.T) {
+	if os.Getenv("TEST_RUN_CONTEXT_SIGNAL") != "" {
+		// run as the interrupted process
+		cmds := Commands{
+			"slow": func() string {
+				fmt.Println("started")
+				time.Sleep(5 * time.Second)
+				return "finished"
+			},
+		}
+		out, _ := cmds.RunContext(context.Background(), "slow")
+		fmt.Println(out...)
+		os.Exit(0)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupt signals can not be sent on windows")
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCommands_RunContext_Signal$")
+	cmd.Env = append(os.Environ(), "TEST_RUN_CONTEXT_SIGNAL=1")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "started\n" {
+		t.Fatalf("expected slow function to start, found %q, %v", line, err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	rest, _ := ioutil.ReadAll(stdout)
+	err = cmd.Wait()
+	if err == nil || strings.Contains(string(rest), "finished") {
+		t.Fatalf("expected second interrupt to terminate the function, found %v, %q", err, rest)
+	}
+}
+
+type testService struct {
+	name string
+}
+
+func TestCommands_Run_Inject(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	wt := reflect.TypeOf((*io.Writer)(nil)).Elem()
+	functions.NewInjectedType(wt, func() interface{} { return buf })
+	defer functions.NewInjectedType(wt, func() interface{} { return os.Stdout })
+	rt := reflect.TypeOf((*io.Reader)(nil)).Elem()
+	functions.NewInjectedType(rt, func() interface{} { return strings.NewReader("testinput") })
+	defer functions.NewInjectedType(rt, func() interface{} { return os.Stdin })
+
+	var found Commands
+	svc := &testService{name: "testservice"}
+	cmds := Commands{
+		"write": func(out io.Writer, s string, svc *testService, c Commands, variadic ...string) {
+			found = c
+			fmt.Fprintf(out, "%s %s %s", s, svc.name, strings.Join(variadic, ","))
+		},
+	}
+	cmds.Inject(svc)
+	if _, err := cmds.Run("write", "teststring", "a", "b"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if buf.String() != "teststring testservice a,b" {
+		t.Fatalf("unexpected output, found %q", buf.String())
+	}
+	if found == nil || found["write"] == nil {
+		t.Fatalf("expected Commands to be injected")
+	}
+	if _, err := cmds.Run("write"); err == nil {
+		t.Fatalf("expected missing argument error, found none")
+	}
+
+	var read string
+	var file io.ReadCloser
+	reads := Commands{
+		"read": func(in io.Reader, f io.ReadCloser) error {
+			by, err := ioutil.ReadAll(in)
+			read, file = string(by), f
+			return err
+		},
+	}
+	if _, err := reads.Run("read", "commands_test.go"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if read != "testinput" {
+		t.Fatalf("expected io.Reader to be injected, found %q", read)
+	}
+	f, ok := file.(*os.File)
+	if !ok || f.Name() != "commands_test.go" {
+		t.Fatalf("expected io.ReadCloser to be parsed as a file name, found %v", file)
+	}
+	f.Close()
+
+	sig := functions.NewSignature(cmds["write"], reflect.TypeOf(svc), reflect.TypeOf(cmds))
+	if len(sig.ParamTypes) != 2 || len(sig.InjectedTypes) != 3 {
+		t.Fatalf("unexpected signature, %d parameters and %d injected", len(sig.ParamTypes), len(sig.InjectedTypes))
+	}
+}
+
+type testDeployOptions struct {
+	DryRun   bool
+	Region   string   `cmd:"region,r,the region to deploy to"`
+	Tags     []string `cmd:",t"`
+	Replicas int
+	Internal string `cmd:"-"`
+}
+
+func TestCommands_Run_Options(t *testing.T) {
+	ot := reflect.TypeOf(testDeployOptions{})
+	functions.NewOptionsType(ot)
+	defer functions.RemoveOptionsType(ot)
+
+	var found testDeployOptions
+	var foundRepo *url.URL
+	cmds := Commands{
+		"--verbose": &testVarBool,
+		"deploy": func(opts testDeployOptions, repo *url.URL) {
+			found = opts
+			foundRepo = repo
+		},
+	}
+	_, err := cmds.Run("--verbose", "deploy", "--dry-run", "http://example.com/repo", "-r", "eu", "-t", "a", "--tags", "b", "--replicas=3")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if foundRepo == nil || foundRepo.String() != "http://example.com/repo" {
+		t.Fatalf("unexpected repo parameter, %v", foundRepo)
+	}
+	if !found.DryRun || found.Region != "eu" || strings.Join(found.Tags, ",") != "a,b" || found.Replicas != 3 {
+		t.Fatalf("unexpected options, %+v", found)
+	}
+
+	if _, err := cmds.Run("deploy", "--internal", "x", "http://example.com/repo"); err == nil {
+		t.Fatalf("expected unexpected flag error for hidden field, found none")
+	}
+	if _, err := cmds.Run("deploy", "http://example.com/repo"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if found.DryRun || found.Region != "" {
+		t.Fatalf("expected empty options, found %+v", found)
+	}
+}
+
+type testPoster struct {
+	ContentType          string      `aliases:"--contenttype,-ct" env:"TEST_POSTER_CONTENT_TYPE" help:"format of the data"`
+	LocalFilePermissions os.FileMode `cmd:"permissions" aliases:"--perm,-p" default:"416"`
+	LocalFileRoot        string      `cmd:"root,r,the local root directory"`
+	Hidden               string      `cmd:"-"`
+	internal             string
+}
+
+func (p *testPoster) PostLocal(fn string) string {
+	return strings.Join([]string{fn, p.ContentType, p.LocalFilePermissions.String()}, " ")
+}
+
+func TestFromStruct(t *testing.T) {
+	p := &testPoster{}
+	cmds := FromStruct(p).Merge(Commands{
+		"--verbose": &testVarBool,
+		"-ct":       &p.ContentType,
+	})
+	if p.LocalFilePermissions != 0640 {
+		t.Fatalf("unexpected default, expected %o, found %o", 0640, p.LocalFilePermissions)
+	}
+	for _, k := range []string{"--content-type", "--contenttype", "-ct", "--permissions", "--perm", "-p", "--root", "-r", "post-local", "--verbose"} {
+		if _, ok := cmds.findKey(k); !ok {
+			t.Fatalf("expected key %s in map", k)
+		}
+	}
+	if _, ok := cmds["--hidden"]; ok {
+		t.Fatalf("unexpected key for hidden field")
+	}
+	if h := cmds.settings().comments["--root"]; h != "the local root directory" {
+		t.Fatalf("unexpected help from cmd tag, found %q", h)
+	}
+
+	os.Setenv("TEST_POSTER_CONTENT_TYPE", "text/plain")
+	defer os.Unsetenv("TEST_POSTER_CONTENT_TYPE")
+	out, err := cmds.Run("post-local", "test.txt", "-p", "420")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || out[0] != "test.txt text/plain -rw-r--r--" {
+		t.Fatalf("unexpected result, %v", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected panic merging conflicting key")
+		}
+	}()
+	cmds.Merge(Commands{"-p": &testVarBool})
+}
+
+func TestCommands_Merge_Aliases(t *testing.T) {
+	var a, b string
+	cmds := Commands{"--a": &a}.Alias("--a", "-x")
+	cmds.Merge(Commands{"-x": &a}, Commands{"--b": &a}.Alias("--b", "-x"))
+	if _, ok := cmds.findKey("--b"); !ok {
+		t.Fatalf("expected key --b merged")
+	}
+	for _, m := range []Commands{
+		{"--a": &b},
+		{"-x": &b},
+		Commands{"--c": &b}.Alias("--c", "--a"),
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected panic merging %v", m.sortedKeys())
+				}
+			}()
+			cmds.Merge(m)
+		}()
+	}
+}
+
+func TestCommands_Run_Alias(t *testing.T) {
+	var contentType string
+	var verbose bool
+	cmds := Commands{
+		"--content-type": &contentType,
+		"--verbose":      &verbose,
+		"-V":             &verbose,
+		"":               testFunc,
+	}.Alias("--content-type", "--contenttype", "-c").Alias("--verbose", "-v")
+	cmds.ClusterFlags(true)
+
+	if _, err := cmds.Run("teststring", "-vc", "text/plain"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if !verbose || contentType != "text/plain" {
+		t.Fatalf("unexpected values, verbose %v, content type %q", verbose, contentType)
+	}
+	if _, err := cmds.Run("teststring", "--ContentType", "text/html", "--no-v"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if verbose || contentType != "text/html" {
+		t.Fatalf("unexpected values, verbose %v, content type %q", verbose, contentType)
+	}
+	vs, ok := cmds.ValueSource("--content-type")
+	if !ok || vs.Origin != "--ContentType" {
+		t.Fatalf("unexpected value source, %v", vs)
+	}
+
+	if al := strings.Join(cmds.Aliases("--verbose"), ","); al != "-V,-v" {
+		t.Fatalf("unexpected aliases of --verbose, %s", al)
+	}
+	help.HelpLibrary = []*help.HelpSubject{{
+		Name:      "main",
+		HelpItems: []*help.HelpItem{{Key: "--content-type", Comment: "format of the data"}},
+	}}
+	defer func() { help.HelpLibrary = nil }()
+	out, err := cmds.Run("--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "--content-type, --contenttype, -c <string>\t") {
+		t.Fatalf("expected aliases in help, found %v", out)
+	}
+
+	cmds["-c"] = &verbose
+	if _, err := cmds.Run("teststring"); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Fatalf("expected alias conflict error, found %v", err)
+	}
+}
+
+func TestCommands_Complete(t *testing.T) {
+	ct := reflect.TypeOf(testColour(0))
+	values.NewEnum(ct, map[string]interface{}{"red": 1, "green": 2, "blue": 3})
+	defer values.NewEnum(ct, nil)
+
+	var colour testColour
+	var verbose bool
+	var name string
+	cmds := Commands{
+		"--colour":  &colour,
+		"--verbose": &verbose,
+		"--name":    &name,
+		"version":   testFunc,
+		"get": Commands{
+			"":          testFuncUrl,
+			"local":     func(f *os.File, b bool) {},
+			"--headers": &verbose,
+		},
+	}.Alias("--verbose", "-v")
+
+	tests := []struct {
+		words  []string
+		expect []string
+	}{
+		{[]string{""}, []string{"get", "version", ":1"}},
+		{[]string{"--v"}, []string{"--verbose", ":1"}},
+		{[]string{"-"}, []string{"--colour", "--help\tshows this help", "--name", "--no-v", "--no-verbose",
+			"--show-config\tshows the value of each assignment and where it was set, rather than running the command",
+			"--verbose", "-?\tshows this help", "-v", ":1"}},
+		{[]string{"get", "--"}, []string{"--colour", "--headers", "--help\tshows this help", "--name", "--no-headers", "--no-v", "--no-verbose",
+			"--show-config\tshows the value of each assignment and where it was set, rather than running the command", "--verbose", ":1"}},
+		{[]string{"get", "local", "--colour", "g"}, []string{"green", ":1"}},
+		{[]string{"--colour", "r"}, []string{"red", ":1"}},
+		{[]string{"--colour=b"}, []string{"--colour=blue", ":1"}},
+		{[]string{"--name", ""}, []string{":0"}},
+		{[]string{"-v", "get", "l"}, []string{"local", ":1"}},
+		{[]string{"get", "local", ""}, []string{":0"}},
+		{[]string{"get", "local", "file.txt", ""}, []string{":1"}},
+		{[]string{"version", "text", ""}, []string{":1"}},
+	}
+	for _, test := range tests {
+		found := cmds.Complete(test.words...)
+		if strings.Join(found, "|") != strings.Join(test.expect, "|") {
+			t.Fatalf("unexpected completion of %v, expected %v, found %v", test.words, test.expect, found)
+		}
+	}
+
+	// completed flags are accepted by Run in the same position
+	verbose = true
+	if _, err := cmds.Run("get", "local", "commands_test.go", "true", "--no-v"); err != nil || verbose {
+		t.Fatalf("unexpected run of completed flags, %v, %v", err, verbose)
+	}
+
+	out, err := cmds.Run(CompleteCommand, "--colour", "g")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 2 || out[0] != "green" {
+		t.Fatalf("unexpected completion result, %v", out)
+	}
+	out, err = cmds.Run(CompletionCommand, "zsh")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "#compdef") {
+		t.Fatalf("unexpected completion script, %v", out)
+	}
+	if _, err := cmds.Run(CompletionCommand, "cmd.exe"); err == nil {
+		t.Fatalf("expected error for unsupported shell, found none")
+	}
+}
+
+func TestCommands_Complete_Providers(t *testing.T) {
+	var root, format string
+	var verbose bool
+	files := map[string][]string{
+		"./content": {"index.html", "info.txt"},
+		"/tmp":      {"tmp.txt"},
+	}
+	var foundFlags map[string][]string
+	get := Commands{
+		"--root": &root,
+		"local":  func(fn string, more ...string) {},
+	}.CompleteParam("local", 0, func(token string, flags map[string][]string) []completion.Candidate {
+		foundFlags = flags
+		dir := "./content"
+		if r, ok := flags["--root"]; ok {
+			dir = r[len(r)-1]
+		}
+		var candidates []completion.Candidate
+		for _, f := range files[dir] {
+			candidates = append(candidates, completion.Candidate{Value: f, Description: "file in " + dir})
+		}
+		return candidates
+	})
+	cmds := Commands{
+		"--format":  &format,
+		"--verbose": &verbose,
+		"get":       get,
+	}.CompleteFlag("--format", func(token string, flags map[string][]string) []completion.Candidate {
+		return []completion.Candidate{{Value: "json"}, {Value: "text", Description: "plain text"}}
+	})
+
+	tests := []struct {
+		words  []string
+		expect []string
+	}{
+		{[]string{"--format", ""}, []string{"json", "text\tplain text", ":1"}},
+		{[]string{"--format=t"}, []string{"--format=text\tplain text", ":1"}},
+		{[]string{"get", "local", "in"}, []string{"index.html\tfile in ./content", "info.txt\tfile in ./content", ":1"}},
+		{[]string{"--verbose", "get", "--root=/tmp", "local", ""}, []string{"tmp.txt\tfile in /tmp", ":1"}},
+		{[]string{"get", "--root", "/nowhere", "local", ""}, []string{":0"}},
+	}
+	for _, test := range tests {
+		found := cmds.Complete(test.words...)
+		if strings.Join(found, "|") != strings.Join(test.expect, "|") {
+			t.Fatalf("unexpected completion of %v, expected %q, found %q", test.words, test.expect, found)
+		}
+	}
+	cmds.Complete("--no-verbose", "get", "--root", "/tmp", "local", "")
+	if strings.Join(foundFlags["--verbose"], ",") != "false" || strings.Join(foundFlags["--root"], ",") != "/tmp" {
+		t.Fatalf("unexpected flags given to provider, %v", foundFlags)
+	}
+}
+
+func TestCommands_Run_GeneratedHelp(t *testing.T) {
+	port := 8080
+	var verbose bool
+	var name string
+	cmds := Commands{
+		"--port":    &port,
+		"-p":        &port,
+		"--verbose": &verbose,
+		"--name":    &name,
+		"get":       func(u *url.URL, headers ...string) {},
+		"remote": Commands{
+			"add": func(name string, u *url.URL) {},
+		},
+	}
+	cmds.setComment("--port", "port to listen on")
+
+	out, err := cmds.Run("--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("expected generated help, found %v", out)
+	}
+	s := out[0].(string)
+	for _, expect := range []string{
+		"[flags] <command>",
+		"--port, -p <int>\tport to listen on (default: 8080)",
+		"--verbose, --no-verbose\t",
+		"--name <string>\t",
+		"get <url.URL> [<string>...]\t",
+		"remote <command>\t",
+		"--help, -?\tshows this help",
+	} {
+		if !strings.Contains(s, expect) {
+			t.Fatalf("expected %q in help, found %s", expect, s)
+		}
+	}
+	if strings.Contains(s, "\n-p") {
+		t.Fatalf("expected -p to be grouped with --port, found %s", s)
+	}
+
+	out, err = cmds.Run("remote", "--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 1 || !strings.Contains(out[0].(string), "add <string> <url.URL>\t") {
+		t.Fatalf("expected help of remote, found %v", out)
+	}
+
+	out, err = cmds.Run("get", "--help")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 2 || !strings.HasPrefix(out[0].(string), "get <url.URL> [<string>...]") {
+		t.Fatalf("expected help of get, found %v", out)
+	}
+}
+
+func TestCommands_References(t *testing.T) {
+	port := 8080
+	var verbose bool
+	cmds := Commands{
+		"--port":    &port,
+		"--verbose": &verbose,
+		"":          func() {},
+		"remote": Commands{
+			"add": func(name string, u *url.URL) {},
+		},
+	}.EnvPrefix("APP")
+	cmds.setComment("--port", "port to listen on")
+	help.HelpLibrary = []*help.HelpSubject{
+		{Name: "main", Comment: "app runs things"},
+		{Name: "remote", Comment: "remote manages remotes", HelpItems: []*help.HelpItem{{Key: "add", Comment: "adds a remote"}}},
+	}
+	defer func() { help.HelpLibrary = nil }()
+
+	refs := cmds.References("app")
+	if len(refs) != 3 || refs[0].Name != "app" || refs[1].Name != "app remote" || refs[2].Name != "app remote add" {
+		t.Fatalf("unexpected references, %v", refs)
+	}
+	if strings.Join(refs[2].Synopsis, "|") != "[flags] <string> <url.URL>" || refs[2].Description != "adds a remote" {
+		t.Fatalf("unexpected command reference, %q, %q", refs[2].Synopsis, refs[2].Description)
+	}
+	if strings.Join(refs[0].Synopsis, "|") != "[flags] <command>|[flags]" {
+		t.Fatalf("unexpected synopsis, %q", refs[0].Synopsis)
+	}
+
+	dir, err := ioutil.TempDir("", "references")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files, err := cmds.WriteReferences(dir, ReferenceMan, "app")
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(files) != 3 || filepath.Base(files[1]) != "app-remote.1" || filepath.Base(files[2]) != "app-remote-add.1" {
+		t.Fatalf("unexpected man pages, %v", files)
+	}
+	by, err := ioutil.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	for _, expect := range []string{
+		".SH NAME\napp \\- app runs things",
+		".SH SYNOPSIS\n.B app\n[flags] <command>",
+		".SH OPTIONS\n.TP\n.B \\-\\-port <int>\nport to listen on\n.br\nDefault: 8080",
+		".SH ENVIRONMENT\n.TP\n.B APP_PORT\nSets \\-\\-port",
+		".BR app\\-remote (1)",
+	} {
+		if !strings.Contains(string(by), expect) {
+			t.Fatalf("expected %q in man page, found %s", expect, by)
+		}
+	}
+
+	out, err := cmds.Run(ReferenceCommand, ReferenceMarkdown, dir)
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if len(out) != 3 {
+		t.Fatalf("expected three markdown pages, found %v", out)
+	}
+	by, err = ioutil.ReadFile(out[1].(string))
+	if err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	prog := filepath.Base(os.Args[0])
+	for _, expect := range []string{
+		fmt.Sprintf("# %s remote\n\nremote manages remotes", prog),
+		fmt.Sprintf("- [`add <string> <url.URL>`](%s-remote-add.md) - adds a remote", prog),
+		fmt.Sprintf("- [%s](%s.md)", prog, prog),
+	} {
+		if !strings.Contains(string(by), expect) {
+			t.Fatalf("expected %q in markdown page, found %s", expect, by)
+		}
+	}
+	if _, err := cmds.Run(ReferenceCommand, "html"); err == nil {
+		t.Fatalf("expected error for unknown format, found none")
+	}
+}
@@ -0,0 +1,382 @@
+package commandgo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/eurozulu/commandgo/arguments"
+	"github.com/eurozulu/commandgo/completion"
+	"github.com/eurozulu/commandgo/functions"
+	"github.com/eurozulu/commandgo/help"
+	"github.com/eurozulu/commandgo/values"
+)
+
+const (
+	// CompleteCommand is the hidden command, answered by Run, with the completion candidates of the following words.
+	CompleteCommand = "__complete"
+
+	// CompletionCommand is the hidden command, answered by Run, with the completion script of the following shell name.
+	// e.g. `source <(restline __completion bash)`
+	CompletionCommand = "__completion"
+)
+
+var fileType = reflect.TypeOf(&os.File{})
+
+// runCompletion answers the hidden completion commands.
+// returns false if the arguments are not a completion command
+func (c Commands) runCompletion(args []string) ([]interface{}, bool, error) {
+	if len(args) == 0 {
+		return nil, false, nil
+	}
+	switch args[0] {
+	case CompleteCommand:
+		var lines []interface{}
+		for _, l := range c.Complete(args[1:]...) {
+			lines = append(lines, l)
+		}
+		return lines, true, nil
+
+	case CompletionCommand:
+		shell := "bash"
+		if len(args) > 1 {
+			shell = args[1]
+		}
+		s, err := completion.Script(shell, filepath.Base(os.Args[0]))
+		if err != nil {
+			return nil, true, err
+		}
+		return []interface{}{s}, true, nil
+	}
+	return nil, false, nil
+}
+
+// Complete gets the completion lines for the given words of a command line, the last of which is the word being completed.
+// See the completion package for the format of the lines.
+func (c Commands) Complete(words ...string) []string {
+	token := ""
+	if len(words) > 0 {
+		token = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	candidates, d := c.completeWords(words, token, map[string][]string{}, nil)
+	return completion.Format(completion.Filter(candidates, token), d)
+}
+
+// CompleteFlag registers the given Provider to complete the values of the flag of the given key.
+// panics if the key is not mapped to an assignment.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) CompleteFlag(key string, p completion.Provider) Commands {
+	cmd, ok := c[key]
+	if !ok || !c.isAssignment(cmd) {
+		panic(fmt.Sprintf("completed flag %s is not mapped to an assignment", key))
+	}
+	s := c.ensureSettings()
+	if s.flagCompletions == nil {
+		s.flagCompletions = map[string]completion.Provider{}
+	}
+	s.flagCompletions[key] = p
+	return c
+}
+
+// CompleteParam registers the given Provider to complete the parameter, at the given position, of the function mapped to the given key.
+// pos is the position of the parameter, starting at zero. The Provider of a variadic parameter completes each of its arguments.
+// panics if the key is not mapped to a function.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) CompleteParam(key string, pos int, p completion.Provider) Commands {
+	cmd, ok := c[key]
+	if !ok || !functions.IsFunc(cmd) {
+		panic(fmt.Sprintf("completed key %s is not mapped to a function", key))
+	}
+	s := c.ensureSettings()
+	if s.paramCompletions == nil {
+		s.paramCompletions = map[string]map[int]completion.Provider{}
+	}
+	if s.paramCompletions[key] == nil {
+		s.paramCompletions[key] = map[int]completion.Provider{}
+	}
+	s.paramCompletions[key][pos] = p
+	return c
+}
+
+// completeWords finds the candidates for the given token, following the given, preceding words through this map and its sub maps.
+// flags collects the values of the flags found in the words, keyed by the flag key.
+// parents are the maps leading to this map, from the top map, whose flags are also accepted following the commands of this map.
+func (c Commands) completeWords(words []string, token string, flags map[string][]string, parents []Commands) ([]completion.Candidate, completion.Directive) {
+	var cmdKey string
+	var cmdFound bool
+	var params int
+	chain := append(parents[:len(parents):len(parents)], c)
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if arguments.IsFlag(w) && !isTerminated(words[:i]) {
+			nv := strings.SplitN(w, "=", 2)
+			m, k, ok := findFlag(chain, nv[0])
+			switch {
+			case len(nv) > 1:
+				if !ok {
+					k = nv[0]
+				}
+				flags[k] = append(flags[k], nv[1])
+			case ok && m.takesValue(k):
+				if i+1 == len(words) {
+					// token is the value of the flag
+					return m.completeValue(k, token, flags)
+				}
+				i++
+				flags[k] = append(flags[k], words[i])
+			case ok && values.IsKind(m[k], reflect.Bool):
+				flags[k] = append(flags[k], "true")
+			case ok:
+				flags[k] = append(flags[k], "")
+			default:
+				if nk, nok := findNegatedFlag(chain, w); nok {
+					flags[nk] = append(flags[nk], "false")
+				} else {
+					flags[w] = append(flags[w], "")
+				}
+			}
+			continue
+		}
+		if w == arguments.Terminator {
+			continue
+		}
+		if !cmdFound {
+			k, ok := c.findKey(w)
+			if !ok {
+				if k, ok = c.findKey(""); ok {
+					params++
+				}
+			}
+			if ok && c.isSubmap(c[k]) {
+				if k == "" {
+					return c[k].(Commands).completeWords(words[i:], token, flags, chain)
+				}
+				return c[k].(Commands).completeWords(words[i+1:], token, flags, chain)
+			}
+			cmdKey, cmdFound = k, true
+			continue
+		}
+		params++
+	}
+
+	// a token of just the terminator is taken to be the start of a long flag
+	if (arguments.IsFlag(token) || token == arguments.Terminator) && !isTerminated(words) {
+		if i := strings.Index(token, "="); i > 0 {
+			if m, k, ok := findFlag(chain, token[:i]); ok && m.takesValue(k) {
+				candidates, d := m.completeValue(k, token[i+1:], flags)
+				for j := range candidates {
+					candidates[j].Value = token[:i+1] + candidates[j].Value
+				}
+				return candidates, d
+			}
+			return nil, completion.DirectiveNoFiles
+		}
+		var candidates []completion.Candidate
+		for _, m := range parents {
+			candidates = append(candidates, m.flagCandidates("")...)
+		}
+		candidates = append(candidates, c.flagCandidates(cmdKey)...)
+		return append(candidates, builtinCandidates(chain)...), completion.DirectiveNoFiles
+	}
+
+	if !cmdFound {
+		candidates := c.commandCandidates()
+		if k, ok := c.findKey(""); ok && c.isSubmap(c[k]) {
+			sc, _ := c[k].(Commands).completeWords(nil, token, flags, chain)
+			return append(candidates, sc...), completion.DirectiveNoFiles
+		} else if ok {
+			pc, d := c.completeParam(k, 0, token, flags)
+			return append(candidates, pc...), d
+		}
+		return candidates, completion.DirectiveNoFiles
+	}
+	return c.completeParam(cmdKey, params, token, flags)
+}
+
+// completeValue finds the candidates for the value of the given flag key
+func (c Commands) completeValue(key, token string, flags map[string][]string) ([]completion.Candidate, completion.Directive) {
+	cmd := c[key]
+	if !c.isAssignment(cmd) {
+		return nil, completion.DirectiveDefault
+	}
+	return provided(c.settings().flagCompletions[key], token, flags, reflect.TypeOf(cmd).Elem())
+}
+
+// completeParam finds the candidates for the parameter, at the given position, of the command mapped to the given key
+func (c Commands) completeParam(key string, pos int, token string, flags map[string][]string) ([]completion.Candidate, completion.Directive) {
+	cmd, ok := c[key]
+	if !ok {
+		return nil, completion.DirectiveNoFiles
+	}
+	if c.isAssignment(cmd) {
+		if pos > 0 {
+			return nil, completion.DirectiveNoFiles
+		}
+		return c.completeValue(key, token, flags)
+	}
+	if !functions.IsFunc(cmd) {
+		return nil, completion.DirectiveNoFiles
+	}
+	sig := c.caller(context.Background(), key).Signature(cmd)
+	last := len(sig.ParamTypes) - 1
+	if sig.IsVariadic && pos > last {
+		pos = last
+	}
+	if pos > last {
+		return nil, completion.DirectiveNoFiles
+	}
+	t := sig.ParamTypes[pos]
+	if sig.IsVariadic && pos == last {
+		t = t.Elem()
+	}
+	return provided(c.settings().paramCompletions[key][pos], token, flags, t)
+}
+
+// provided gets the candidates from the given Provider, when not nil, otherwise the candidates of the given type.
+// When the Provider has no candidates, the shell is allowed to complete as it would for the type.
+func provided(p completion.Provider, token string, flags map[string][]string, t reflect.Type) ([]completion.Candidate, completion.Directive) {
+	tc, d := typeCandidates(t)
+	if p == nil {
+		return tc, d
+	}
+	if candidates := p(token, flags); len(candidates) > 0 {
+		return candidates, completion.DirectiveNoFiles
+	}
+	return nil, d
+}
+
+// commandCandidates gets the command keys of this map, with their aliases.
+func (c Commands) commandCandidates() []completion.Candidate {
+	var candidates []completion.Candidate
+	for _, k := range c.sortedKeys() {
+		if k == "" || strings.HasPrefix(k, "-") {
+			continue
+		}
+		candidates = append(candidates, completion.Candidate{Value: k, Description: c.description(k)})
+		for _, al := range c.Aliases(k) {
+			candidates = append(candidates, completion.Candidate{Value: al, Description: c.description(k)})
+		}
+	}
+	return candidates
+}
+
+// flagCandidates gets the flags of this map, with their aliases and negations, along with those of the options of the given command.
+func (c Commands) flagCandidates(cmdKey string) []completion.Candidate {
+	var candidates []completion.Candidate
+	for _, k := range c.sortedKeys() {
+		if !strings.HasPrefix(k, "-") {
+			continue
+		}
+		names := append([]string{k}, c.Aliases(k)...)
+		if c.isNegatable(k) {
+			for _, n := range names {
+				if strings.HasPrefix(n, "-") {
+					names = append(names, negatedKey(n))
+				}
+			}
+		}
+		for _, n := range names {
+			candidates = append(candidates, completion.Candidate{Value: n, Description: c.description(k)})
+		}
+	}
+	if cmd, ok := c[cmdKey]; ok && functions.IsFunc(cmd) {
+		for _, t := range c.caller(context.Background(), cmdKey).Signature(cmd).InjectedTypes {
+			if !functions.IsOptionsType(t) {
+				continue
+			}
+			for _, opt := range functions.Options(t) {
+				candidates = append(candidates, completion.Candidate{Value: opt.Name, Description: opt.Help})
+				if opt.Short != "" {
+					candidates = append(candidates, completion.Candidate{Value: opt.Short, Description: opt.Help})
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// builtinCandidates gets the flags added by the framework, the help and show config flags, not already mapped in the given maps.
+// They are added to the maps when Run, so may not yet be keys.
+func builtinCandidates(maps []Commands) []completion.Candidate {
+	var candidates []completion.Candidate
+	for _, k := range []string{help.HelpFlagFull, help.HelpFlagShort, ShowConfigFlag} {
+		if _, _, ok := findFlag(maps, k); !ok {
+			candidates = append(candidates, completion.Candidate{Value: k, Description: builtinComments[k]})
+		}
+	}
+	return candidates
+}
+
+// findFlag finds the map, of the given maps, and the key the given flag is mapped to, searching the maps in order.
+func findFlag(maps []Commands, flag string) (Commands, string, bool) {
+	for _, m := range maps {
+		if k, ok := m.findKey(flag); ok {
+			return m, k, true
+		}
+	}
+	return nil, "", false
+}
+
+// findNegatedFlag finds the bool flag key, in the given maps, which the given flag is the negated form of.
+func findNegatedFlag(maps []Commands, flag string) (string, bool) {
+	for _, m := range maps {
+		if k, ok := m.findNegatedKey(flag); ok {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// takesValue checks if the flag of the given key is followed by a value.
+// Only assignments, other than bools and counters, take a value.
+func (c Commands) takesValue(key string) bool {
+	cmd := c[key]
+	return c.isAssignment(cmd) && !values.IsKind(cmd, reflect.Bool) && !c.isCounter(key)
+}
+
+// description gets the help text of the given key, from its comment or the help library.
+func (c Commands) description(key string) string {
+	if cm, ok := c.settings().comments[key]; ok {
+		return cm
+	}
+	if hi := help.FindItem(key); hi != nil {
+		return hi.Comment
+	}
+	return ""
+}
+
+// typeCandidates gets the candidates for a value of the given type.
+// Enumerations complete with their choices, bools with nothing and files, or strings, with file names.
+func typeCandidates(t reflect.Type) ([]completion.Candidate, completion.Directive) {
+	if values.IsEnum(t) {
+		var candidates []completion.Candidate
+		for _, n := range values.EnumChoices(t) {
+			candidates = append(candidates, completion.Candidate{Value: n})
+		}
+		return candidates, completion.DirectiveNoFiles
+	}
+	if fileType.AssignableTo(t) {
+		return nil, completion.DirectiveDefault
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.String {
+		return nil, completion.DirectiveDefault
+	}
+	return nil, completion.DirectiveNoFiles
+}
+
+// isTerminated checks if the given words contain the terminator, after which there are no flags
+func isTerminated(words []string) bool {
+	for _, w := range words {
+		if w == arguments.Terminator {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+// Package completion generates shell completion scripts and formats the candidates they complete with.
+// The scripts call the application with the hidden command "__complete", followed by the words of the command line
+// up to and including the word being completed.  The application answers with a line for each candidate,
+// the value and its description separated by a tab, followed by a final line of the Directive, in the form ":<directive>".
+package completion
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Candidate is a possible value of the word being completed, with an optional description of that value.
+type Candidate struct {
+	Value       string
+	Description string
+}
+
+func (cd Candidate) String() string {
+	if cd.Description == "" {
+		return cd.Value
+	}
+	return strings.Join([]string{cd.Value, firstLine(cd.Description)}, "\t")
+}
+
+// Provider provides the candidates for the word being completed, the token, which is the partially typed value.
+// flags are the values of the flags preceding the token, keyed by the flag key.
+// Flags without a value, such as bools, have a value of "true", or "false" when negated, and counters an empty string.
+type Provider func(token string, flags map[string][]string) []Candidate
+
+// Directive tells the shell how to complete when there are no candidates.
+type Directive int
+
+const (
+	// DirectiveDefault allows the shell to use its own completion, usually of file names.
+	DirectiveDefault Directive = iota
+	// DirectiveNoFiles prevents the shell completing with anything other than the candidates.
+	DirectiveNoFiles
+)
+
+// Format formats the given candidates and directive into the lines answered to a completion script.
+func Format(candidates []Candidate, d Directive) []string {
+	lines := make([]string, 0, len(candidates)+1)
+	for _, cd := range candidates {
+		lines = append(lines, cd.String())
+	}
+	return append(lines, fmt.Sprintf(":%d", d))
+}
+
+// Filter gets the candidates whose value begins with the given prefix, sorted by value.
+// Candidates with the same value are only included once.
+func Filter(candidates []Candidate, prefix string) []Candidate {
+	var found []Candidate
+	seen := map[string]bool{}
+	for _, cd := range candidates {
+		if !strings.HasPrefix(cd.Value, prefix) || seen[cd.Value] {
+			continue
+		}
+		seen[cd.Value] = true
+		found = append(found, cd)
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Value < found[j].Value
+	})
+	return found
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(s, "\n", 2)[0]
+}
@@ -0,0 +1,37 @@
+package completion_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/completion"
+)
+
+func TestFormat(t *testing.T) {
+	candidates := completion.Filter([]completion.Candidate{
+		{Value: "post", Description: "posts data\nto a url"},
+		{Value: "get", Description: "gets a url"},
+		{Value: "put"},
+		{Value: "get"},
+	}, "p")
+	lines := completion.Format(candidates, completion.DirectiveNoFiles)
+	expect := []string{"post\tposts data", "put", ":1"}
+	if strings.Join(lines, "|") != strings.Join(expect, "|") {
+		t.Fatalf("unexpected lines, expected %q, found %q", expect, lines)
+	}
+}
+
+func TestScript(t *testing.T) {
+	for _, shell := range completion.Shells() {
+		s, err := completion.Script(shell, "my-app")
+		if err != nil {
+			t.Fatalf("unexpected error, %v", err)
+		}
+		if !strings.Contains(s, "my-app __complete") || !strings.Contains(s, "my_app") {
+			t.Fatalf("unexpected %s script, %s", shell, s)
+		}
+	}
+	if _, err := completion.Script("cmd.exe", "my-app"); err == nil {
+		t.Fatalf("expected error for unsupported shell, found none")
+	}
+}
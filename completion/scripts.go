@@ -0,0 +1,100 @@
+package completion
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const bashScript = `# bash completion for {{prog}}
+_{{fn}}_complete() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    local IFS=$'\n'
+    local lines=($({{prog}} __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+    local last=$((${#lines[@]} - 1))
+    local directive="${lines[$last]#:}"
+    unset "lines[$last]"
+    COMPREPLY=()
+    local line
+    for line in "${lines[@]}"; do
+        COMPREPLY+=("${line%%$'\t'*}")
+    done
+    if [[ ${#COMPREPLY[@]} -eq 0 && "$directive" != "1" ]]; then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+complete -F _{{fn}}_complete {{prog}}
+`
+
+const zshScript = `#compdef {{prog}}
+# zsh completion for {{prog}}
+_{{fn}}() {
+    local -a lines completions
+    local directive line value
+    lines=("${(@f)$({{prog}} __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    directive="${lines[-1]#:}"
+    lines=("${(@)lines[1,-2]}")
+    for line in "${lines[@]}"; do
+        [[ -z "$line" ]] && continue
+        value="${line%%$'\t'*}"
+        value="${value//:/\\:}"
+        if [[ "$line" == *$'\t'* ]]; then
+            completions+=("${value}:${line#*$'\t'}")
+        else
+            completions+=("${value}")
+        fi
+    done
+    if (( ${#completions} )); then
+        _describe 'values' completions
+    elif [[ "$directive" != "1" ]]; then
+        _files
+    fi
+}
+compdef _{{fn}} {{prog}}
+`
+
+const fishScript = `# fish completion for {{prog}}
+function __{{fn}}_complete
+    set -l args (commandline -opc)[2..-1] (commandline -ct)
+    set -l lines ({{prog}} __complete $args 2>/dev/null)
+    set -l directive (string replace ':' '' -- $lines[-1])
+    set -e lines[-1]
+    for line in $lines
+        echo $line
+    end
+    if test (count $lines) -eq 0; and test "$directive" != "1"
+        __fish_complete_path (commandline -ct)
+    end
+end
+complete -c {{prog}} -f -a '(__{{fn}}_complete)'
+`
+
+var scripts = map[string]string{
+	"bash": bashScript,
+	"zsh":  zshScript,
+	"fish": fishScript,
+}
+
+var nonWord = regexp.MustCompile(`\W`)
+
+// Shells gets the names of the shells scripts can be generated for.
+func Shells() []string {
+	var names []string
+	for k := range scripts {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Script generates the completion script of the given shell for the given program name.
+// returns an error if the shell is not supported.
+func Script(shell, program string) (string, error) {
+	s, ok := scripts[strings.ToLower(shell)]
+	if !ok {
+		return "", fmt.Errorf("%s is not a supported shell, must be one of %s", shell, strings.Join(Shells(), ", "))
+	}
+	s = strings.ReplaceAll(s, "{{fn}}", nonWord.ReplaceAllString(program, "_"))
+	return strings.ReplaceAll(s, "{{prog}}", program), nil
+}
@@ -0,0 +1,107 @@
+package commandgo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eurozulu/commandgo/arguments"
+	"github.com/eurozulu/commandgo/config"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// LoadConfig reads the config file at the given path and applies its values to the assignments in this map and its sub maps.
+// Config keys are the keys of the map, with or without their leading dashes. e.g. 'content-type' sets the '--content-type' flag.
+// Keys within a nested object, block or section are applied to the sub map of that name.
+// Keys repeated in the file, or given as a list, accumulate into slice and map assignments, as repeated flags do.
+// returns an error naming the file, line and key of any unknown key or value which can not be parsed.
+func (c Commands) LoadConfig(path string) error {
+	entries, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	assigned := map[interface{}]bool{}
+	for _, e := range entries {
+		cmds, k, err := c.configAssignment(e)
+		if err != nil {
+			return fmt.Errorf("%s:%d: %v", path, e.Line, err)
+		}
+		cmd := cmds[k]
+		raw := e.Value
+		if assigned[cmd] {
+			err = values.AppendValue(cmd, e.Value, cmds.validators(cmd)...)
+			raw = strings.Join([]string{cmds.settings().sources[cmd].Raw, raw}, values.SliceDelimiter)
+		} else {
+			err = values.SetValue(cmd, e.Value, cmds.validators(cmd)...)
+		}
+		if err != nil {
+			return fmt.Errorf("%s:%d: %s %v", path, e.Line, e, err)
+		}
+		assigned[cmd] = true
+		cmds.recordSource(k, SourceConfig, raw, fmt.Sprintf("%s:%d", path, e.Line))
+	}
+	return nil
+}
+
+// configAssignment finds the map and key of the assignment the given config entry is setting
+func (c Commands) configAssignment(e *config.Entry) (Commands, string, error) {
+	cmds := c
+	for _, name := range e.Path {
+		k, ok := cmds.findKey(name)
+		if !ok || !cmds.isSubmap(cmds[k]) {
+			return nil, "", fmt.Errorf("unknown section %s", name)
+		}
+		cmds = cmds[k].(Commands)
+	}
+	for _, name := range []string{e.Key, "--" + e.Key, "-" + e.Key} {
+		k, ok := cmds.findKey(name)
+		if !ok {
+			continue
+		}
+		if !cmds.isAssignment(cmds[k]) {
+			return nil, "", fmt.Errorf("%s is not a value which can be set", e)
+		}
+		return cmds, k, nil
+	}
+	return nil, "", fmt.Errorf("unknown key %s", e)
+}
+
+// applyConfigFile loads the config file, named by config.ConfigFile, into this map.
+// Any flags in the given arguments, mapped to config.ConfigFile in this map, are applied first and removed from the arguments.
+// When no flag is given, a config file which does not exist is ignored.
+// returns the remaining arguments.
+func (c Commands) applyConfigFile(args []string) ([]string, error) {
+	cf := Commands{}
+	for _, k := range c.sortedKeys() {
+		if cmd := c[k]; cmd == &config.ConfigFile {
+			cf[k] = cmd
+		}
+	}
+	var flagged bool
+	if len(cf) > 0 {
+		cargs := arguments.NewArguments(args)
+		flags, err := cf.matchFlags(cargs)
+		if err != nil {
+			return nil, err
+		}
+		for _, fa := range flags {
+			raw := firstParameter(fa.arg.Parameters)
+			if err := values.SetValue(cf[fa.key], raw); err != nil {
+				return nil, err
+			}
+			c.recordSource(fa.key, SourceFlag, raw, fa.arg.Name)
+			flagged = true
+		}
+		args = cargs.CommandLine()
+	}
+
+	if strings.TrimSpace(config.ConfigFile) == "" {
+		return args, nil
+	}
+	if !flagged {
+		if _, err := os.Stat(config.ConfigFile); os.IsNotExist(err) {
+			return args, nil
+		}
+	}
+	return args, c.LoadConfig(config.ConfigFile)
+}
@@ -0,0 +1,98 @@
+// Package config reads configuration files, providing default values for the assignments mapped in a Commands map.
+// Files may be json, a subset of yaml or ini format. Each format is read into a list of Entries,
+// naming the key of an assignment and the value to set it to.
+// Nested objects (json), indented blocks (yaml) or sections (ini) name the sub maps containing the key.
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigFile is the path of the config file to apply, before the command line is applied.
+// A flag, such as --config, can be mapped to this point to allow the file to be given in the command line.
+// When set before the command line is parsed, it acts as a default file, which is ignored if it does not exist.
+var ConfigFile string
+
+// Entry is a single value read from a config file.
+// Path is the names of the sub maps containing the key, empty for keys in the top map.
+// Line is the line number in the file, where the entry was found.
+type Entry struct {
+	Path  []string
+	Key   string
+	Value string
+	Line  int
+}
+
+func (e Entry) String() string {
+	return strings.Join(append(append([]string{}, e.Path...), e.Key), ".")
+}
+
+// Load reads the config file at the given path into its entries.
+// The file format is determined by the file extension, .json, .yaml / .yml or .ini / .cfg / .conf
+// Files with any other extension are read as json if they begin with a '{', otherwise as ini.
+func Load(path string) ([]*Entry, error) {
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		entries, err = parseJSON(by)
+	case ".yaml", ".yml":
+		entries, err = parseYAML(by)
+	case ".ini", ".cfg", ".conf":
+		entries, err = parseINI(by)
+	default:
+		if bytes.HasPrefix(bytes.TrimSpace(by), []byte("{")) {
+			entries, err = parseJSON(by)
+		} else {
+			entries, err = parseINI(by)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s:%v", path, err)
+	}
+	return entries, nil
+}
+
+// lineError is an error found on a specific line of a config file
+type lineError struct {
+	line int
+	msg  string
+}
+
+func (le lineError) Error() string {
+	return fmt.Sprintf("%d: %s", le.line, le.msg)
+}
+
+// unquote removes any matching single or double quotes surrounding the given value.
+func unquote(s string) string {
+	if len(s) > 1 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// stripComment removes any comment, beginning with one of the given characters, from the given line.
+// Comment characters inside quotes are ignored and must be at the start of the line or follow whitespace.
+func stripComment(line string, markers string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case strings.ContainsRune(markers, r) && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,117 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/config"
+)
+
+const testJSON = `{
+  "verbose": true,
+  "headers": ["a", "b"],
+  "post": {
+    "content-type": "application/json",
+    "perm": 416
+  }
+}`
+
+const testYAML = `# test config
+verbose: true
+headers:
+  - a
+  - "b"
+post:
+  content-type: 'application/json'  # inline comment
+  perm: 416
+`
+
+const testINI = `; test config
+verbose = true
+headers = a
+headers = b
+
+[post]
+content-type: application/json
+perm = 416
+`
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	lines := map[string]int{"test.json": 6, "test.yaml": 8, "test.ini": 8}
+	for name, content := range map[string]string{
+		"test.json": testJSON,
+		"test.yaml": testYAML,
+		"test.ini":  testINI,
+	} {
+		fn := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(fn, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write test file %v", err)
+		}
+		entries, err := config.Load(fn)
+		if err != nil {
+			t.Fatalf("unexpected error loading %s, %v", name, err)
+		}
+		var found []string
+		for _, e := range entries {
+			found = append(found, e.String()+"="+e.Value)
+		}
+		expect := "verbose=true headers=a headers=b post.content-type=application/json post.perm=416"
+		if strings.Join(found, " ") != expect {
+			t.Fatalf("unexpected entries loading %s, expected %s, found %s", name, expect, strings.Join(found, " "))
+		}
+		if entries[4].Line != lines[name] {
+			t.Fatalf("unexpected line number loading %s, expected %d, found %d", name, lines[name], entries[4].Line)
+		}
+	}
+
+	fn := filepath.Join(dir, "bad.yaml")
+	if err := ioutil.WriteFile(fn, []byte("verbose: true\nnonsense\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file %v", err)
+	}
+	_, err := config.Load(fn)
+	if err == nil || !strings.Contains(err.Error(), "bad.yaml:2:") {
+		t.Fatalf("expected error naming file and line, found %v", err)
+	}
+}
+
+func TestLoad_YAMLLists(t *testing.T) {
+	tests := []struct {
+		yaml   string
+		expect string
+	}{
+		{"headers:\n  - a\n  - b\n", "headers=a headers=b"},
+		{"headers:\n- a\n- b\nverbose: true\n", "headers=a headers=b verbose=true"},
+		{"post:\n  headers:\n  - a\n  perm: 416\nverbose: true\n", "post.headers=a post.perm=416 verbose=true"},
+		{"headers: [a, 'b']\n", "headers=a headers=b"},
+		{"headers: []\nverbose: true\n", "verbose=true"},
+		{"headers: [ ]\n", ""},
+	}
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "test.yaml")
+	for _, test := range tests {
+		if err := ioutil.WriteFile(fn, []byte(test.yaml), 0644); err != nil {
+			t.Fatalf("failed to write test file %v", err)
+		}
+		entries, err := config.Load(fn)
+		if err != nil {
+			t.Fatalf("unexpected error loading %q, %v", test.yaml, err)
+		}
+		var found []string
+		for _, e := range entries {
+			found = append(found, e.String()+"="+e.Value)
+		}
+		if strings.Join(found, " ") != test.expect {
+			t.Fatalf("unexpected entries loading %q, expected %s, found %s", test.yaml, test.expect, strings.Join(found, " "))
+		}
+	}
+
+	if err := ioutil.WriteFile(fn, []byte("- a\n"), 0644); err != nil {
+		t.Fatalf("failed to write test file %v", err)
+	}
+	if _, err := config.Load(fn); err == nil || !strings.Contains(err.Error(), "list item has no key") {
+		t.Fatalf("expected list item error, found %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseINI reads the entries from an ini file.
+// Entries are 'key = value' or 'key: value' lines, grouped into [sections] naming the sub map containing them.
+// Sections of nested sub maps are named using dots, e.g. [post.local]
+// Lines beginning with ';' or '#' are comments.
+func parseINI(data []byte) ([]*Entry, error) {
+	var entries []*Entry
+	var section []string
+
+	scn := bufio.NewScanner(bytes.NewReader(data))
+	var line int
+	for scn.Scan() {
+		line++
+		content := strings.TrimSpace(stripComment(scn.Text(), ";#"))
+		if content == "" {
+			continue
+		}
+		if strings.HasPrefix(content, "[") {
+			if !strings.HasSuffix(content, "]") {
+				return nil, lineError{line: line, msg: fmt.Sprintf("section %s is not closed", content)}
+			}
+			section = nil
+			name := strings.TrimSpace(content[1 : len(content)-1])
+			if name != "" {
+				section = strings.Split(name, ".")
+			}
+			continue
+		}
+		i := strings.IndexAny(content, "=:")
+		if i < 1 {
+			return nil, lineError{line: line, msg: fmt.Sprintf("expected 'key = value', found %q", content)}
+		}
+		entries = append(entries, &Entry{
+			Path:  section,
+			Key:   strings.TrimSpace(content[:i]),
+			Value: unquote(strings.TrimSpace(content[i+1:])),
+			Line:  line,
+		})
+	}
+	if err := scn.Err(); err != nil {
+		return nil, lineError{line: line, msg: err.Error()}
+	}
+	return entries, nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// parseJSON reads the entries from a json object.
+// Nested objects are sub maps and arrays give repeated entries of the same key.
+func parseJSON(data []byte) ([]*Entry, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonError(data, dec, err)
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, lineError{line: lineAt(data, dec.InputOffset()), msg: "config must be a json object"}
+	}
+	return parseJSONObject(data, dec, nil)
+}
+
+// parseJSONObject reads the entries of an object, following its opening brace, up to and including its closing brace
+func parseJSONObject(data []byte, dec *json.Decoder, path []string) ([]*Entry, error) {
+	var entries []*Entry
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, jsonError(data, dec, err)
+		}
+		key := tok.(string)
+		line := lineAt(data, dec.InputOffset())
+
+		tok, err = dec.Token()
+		if err != nil {
+			return nil, jsonError(data, dec, err)
+		}
+		switch v := tok.(type) {
+		case json.Delim:
+			if v == '{' {
+				es, err := parseJSONObject(data, dec, append(append([]string{}, path...), key))
+				if err != nil {
+					return nil, err
+				}
+				entries = append(entries, es...)
+				continue
+			}
+			// Array, each item is a repeated entry
+			for dec.More() {
+				tok, err = dec.Token()
+				if err != nil {
+					return nil, jsonError(data, dec, err)
+				}
+				s, err := jsonScalar(tok)
+				if err != nil {
+					return nil, lineError{line: line, msg: fmt.Sprintf("%s %v", key, err)}
+				}
+				entries = append(entries, &Entry{Path: path, Key: key, Value: s, Line: line})
+			}
+			if _, err = dec.Token(); err != nil {
+				return nil, jsonError(data, dec, err)
+			}
+
+		default:
+			s, err := jsonScalar(tok)
+			if err != nil {
+				return nil, lineError{line: line, msg: fmt.Sprintf("%s %v", key, err)}
+			}
+			entries = append(entries, &Entry{Path: path, Key: key, Value: s, Line: line})
+		}
+	}
+	// closing brace
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonError(data, dec, err)
+	}
+	return entries, nil
+}
+
+// jsonScalar gets the string form of a json string, number, bool or null token
+func jsonScalar(tok json.Token) (string, error) {
+	switch v := tok.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", nil
+	default:
+		return "", fmt.Errorf("value must be a string, number or bool")
+	}
+}
+
+func jsonError(data []byte, dec *json.Decoder, err error) error {
+	return lineError{line: lineAt(data, dec.InputOffset()), msg: err.Error()}
+}
+
+// lineAt gets the line number of the given offset in the data
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
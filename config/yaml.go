@@ -0,0 +1,102 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseYAML reads the entries from a subset of yaml.
+// Supports 'key: value' mappings, nested by indentation to name sub maps, lists of values, either as
+// '- value' items, indented within or at the same level as their key, or '[a, b]' flow sequences, quoted values and comments.
+// An empty flow sequence, '[]', has no entries, as with an empty json array.
+// Anchors, multi line values and multiple documents are not supported.
+func parseYAML(data []byte) ([]*Entry, error) {
+	type block struct {
+		indent int
+		name   string
+	}
+	var entries []*Entry
+	var blocks []block
+	path := func() []string {
+		p := make([]string, len(blocks))
+		for i, b := range blocks {
+			p[i] = b.name
+		}
+		return p
+	}
+
+	scn := bufio.NewScanner(bytes.NewReader(data))
+	var line int
+	for scn.Scan() {
+		line++
+		text := strings.TrimRight(stripComment(scn.Text(), "#"), " \t")
+		content := strings.TrimLeft(text, " ")
+		if content == "" || content == "---" {
+			continue
+		}
+		if strings.HasPrefix(content, "\t") {
+			return nil, lineError{line: line, msg: "tabs can not be used for indentation"}
+		}
+		indent := len(text) - len(content)
+		// list items may be indented at the same level as their key
+		isItem := content == "-" || strings.HasPrefix(content, "- ")
+		for len(blocks) > 0 && (blocks[len(blocks)-1].indent > indent || (!isItem && blocks[len(blocks)-1].indent == indent)) {
+			blocks = blocks[:len(blocks)-1]
+		}
+
+		// list item, value of the enclosing key
+		if isItem {
+			if len(blocks) == 0 {
+				return nil, lineError{line: line, msg: "list item has no key"}
+			}
+			p := path()
+			entries = append(entries, &Entry{
+				Path:  p[:len(p)-1],
+				Key:   p[len(p)-1],
+				Value: unquote(strings.TrimSpace(strings.TrimPrefix(content, "-"))),
+				Line:  line,
+			})
+			continue
+		}
+
+		key, value, ok := splitYAMLMapping(content)
+		if !ok {
+			return nil, lineError{line: line, msg: fmt.Sprintf("expected 'key: value', found %q", content)}
+		}
+		if value == "" {
+			// start of a nested block or list
+			blocks = append(blocks, block{indent: indent, name: key})
+			continue
+		}
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			items := strings.TrimSpace(value[1 : len(value)-1])
+			if items == "" {
+				// empty list, no entries
+				continue
+			}
+			for _, v := range strings.Split(items, ",") {
+				entries = append(entries, &Entry{Path: path(), Key: key, Value: unquote(strings.TrimSpace(v)), Line: line})
+			}
+			continue
+		}
+		entries = append(entries, &Entry{Path: path(), Key: key, Value: unquote(value), Line: line})
+	}
+	if err := scn.Err(); err != nil {
+		return nil, lineError{line: line, msg: err.Error()}
+	}
+	return entries, nil
+}
+
+// splitYAMLMapping splits a 'key: value' line into its key and value
+func splitYAMLMapping(s string) (string, string, bool) {
+	if strings.HasSuffix(s, ":") {
+		return unquote(strings.TrimSpace(s[:len(s)-1])), "", true
+	}
+	i := strings.Index(s, ": ")
+	if i < 1 {
+		return "", "", false
+	}
+	return unquote(strings.TrimSpace(s[:i])), strings.TrimSpace(s[i+1:]), true
+}
@@ -0,0 +1,184 @@
+package commandgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConstraintError is returned when a command line breaks one or more of the constraints of a map.
+// Violations describes every constraint which was broken.
+type ConstraintError struct {
+	Violations []string
+}
+
+func (ce ConstraintError) Error() string {
+	return fmt.Sprintf("invalid flags, %s", strings.Join(ce.Violations, ", "))
+}
+
+type constraintType int
+
+const (
+	required constraintType = iota
+	exactlyOne
+	atMostOne
+	allOrNone
+)
+
+// constraint is a rule the flags of a map must follow
+type constraint struct {
+	ctype constraintType
+	keys  []string
+}
+
+func (ct constraint) String() string {
+	switch ct.ctype {
+	case required:
+		return "required"
+	case exactlyOne:
+		return fmt.Sprintf("exactly one of %s", strings.Join(ct.keys, ", "))
+	case atMostOne:
+		return fmt.Sprintf("at most one of %s", strings.Join(ct.keys, ", "))
+	default:
+		return fmt.Sprintf("all or none of %s", strings.Join(ct.keys, ", "))
+	}
+}
+
+// Require declares each of the given keys as required, so must be given whenever this map is run.
+// An assignment set from a config file or environment variable is also considered given.
+// panics if any of the keys are not in this map.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Require(keys ...string) Commands {
+	for _, k := range keys {
+		c.addConstraint(required, k)
+	}
+	return c
+}
+
+// ExactlyOneOf declares that one, and only one, of the given keys must be given whenever this map is run.
+// panics if any of the keys are not in this map.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) ExactlyOneOf(keys ...string) Commands {
+	c.addConstraint(exactlyOne, keys...)
+	return c
+}
+
+// AtMostOneOf declares that no more than one of the given keys may be given, such as flags which conflict with each other.
+// panics if any of the keys are not in this map.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) AtMostOneOf(keys ...string) Commands {
+	c.addConstraint(atMostOne, keys...)
+	return c
+}
+
+// AllOrNone declares that when any of the given keys are given, all of them must be given.
+// panics if any of the keys are not in this map.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) AllOrNone(keys ...string) Commands {
+	c.addConstraint(allOrNone, keys...)
+	return c
+}
+
+func (c Commands) addConstraint(ct constraintType, keys ...string) {
+	for _, k := range keys {
+		if _, ok := c[k]; !ok {
+			panic(fmt.Sprintf("constraint key %s is not mapped", k))
+		}
+	}
+	s := c.ensureSettings()
+	s.constraints = append(s.constraints, &constraint{ctype: ct, keys: keys})
+}
+
+// checkConstraints checks the flags, in the given arguments, follow the constraints of this map and every sub map the arguments lead to.
+// All the maps are checked before any flag or command is invoked, so nothing is run for a command line breaking a constraint.
+// Constraints are not checked when help, or the config, is requested.
+// returns a ConstraintError listing every constraint broken, in all the maps, or nil if all are followed
+func (c Commands) checkConstraints(args []string) error {
+	violations, _ := c.treeViolations(args)
+	if len(violations) > 0 {
+		return &ConstraintError{Violations: violations}
+	}
+	return nil
+}
+
+// treeViolations gets the constraints broken by the flags, in the given arguments, of this map and the sub maps they lead to.
+// Any error in reading the flags is left to be reported when the map is run.
+// returns false, with no violations, if help or the config is requested.
+func (c Commands) treeViolations(args []string) ([]string, bool) {
+	_, cargs, flags, err := c.parseFlags(args)
+	if err != nil {
+		return nil, true
+	}
+	if _, ok := flags.HelpKey(); ok || ShowConfigRequested || flags.hasKey(ShowConfigFlag) {
+		return nil, false
+	}
+	violations := c.violations(flags)
+	if k, ok, err := c.commandKey(cargs); err == nil && ok && c.isSubmap(c[k]) {
+		sv, ok := c[k].(Commands).treeViolations(cargs.CommandLine())
+		if !ok {
+			return nil, false
+		}
+		violations = append(violations, sv...)
+	}
+	return violations, true
+}
+
+// violations gets the constraints of this map broken by the given flags
+func (c Commands) violations(flags flagArgs) []string {
+	var violations []string
+	for _, ct := range c.settings().constraints {
+		var given []string
+		for _, k := range ct.keys {
+			if c.isGiven(k, flags) {
+				given = append(given, k)
+			}
+		}
+		switch {
+		case ct.ctype == required && len(given) == 0:
+			violations = append(violations, fmt.Sprintf("%s is required", ct.keys[0]))
+		case ct.ctype == exactlyOne && len(given) == 0:
+			violations = append(violations, fmt.Sprintf("one of %s is required", strings.Join(ct.keys, ", ")))
+		case (ct.ctype == exactlyOne || ct.ctype == atMostOne) && len(given) > 1:
+			violations = append(violations, fmt.Sprintf("only one of %s may be given, found %s",
+				strings.Join(ct.keys, ", "), strings.Join(given, ", ")))
+		case ct.ctype == allOrNone && len(given) > 0 && len(given) < len(ct.keys):
+			violations = append(violations, fmt.Sprintf("%s must be given together, found only %s",
+				strings.Join(ct.keys, ", "), strings.Join(given, ", ")))
+		}
+	}
+	return violations
+}
+
+// isGiven checks if the given key, or another key mapped to the same assignment, is in the given flags
+// or its assignment has been set from a config file or environment variable.
+// Negated flags are not considered given.
+func (c Commands) isGiven(key string, flags flagArgs) bool {
+	cmd := c[key]
+	isAssign := c.isAssignment(cmd)
+	for _, fa := range flags {
+		if fa.negated {
+			continue
+		}
+		if fa.key == key || (isAssign && c.isAssignment(c[fa.key]) && c[fa.key] == cmd) {
+			return true
+		}
+	}
+	if !isAssign {
+		return false
+	}
+	vs, ok := c.settings().sources[cmd]
+	return ok && vs.Source != SourceDefault
+}
+
+// constraintsOf gets the descriptions of the constraints the given key is part of
+func (c Commands) constraintsOf(key string) []string {
+	var desc []string
+	for _, ct := range c.settings().constraints {
+		for _, k := range ct.keys {
+			if k == key {
+				desc = append(desc, ct.String())
+				break
+			}
+		}
+	}
+	return desc
+}
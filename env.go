@@ -0,0 +1,88 @@
+package commandgo
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eurozulu/commandgo/values"
+)
+
+// Env binds the given key to the environment variable of the given name.
+// When the variable is set, its value is parsed and assigned to the keys assignment before any flags are applied,
+// so a flag given in the command line overrides the environment variable.
+// panics if the key is not mapped to an assignment.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Env(key, name string) Commands {
+	cmd, ok := c[key]
+	if !ok || !c.isAssignment(cmd) {
+		panic(fmt.Sprintf("environment variable %s is not bound to an assignment. %s", name, key))
+	}
+	s := c.ensureSettings()
+	if s.env == nil {
+		s.env = map[string]string{}
+	}
+	s.env[key] = name
+	return c
+}
+
+// EnvPrefix binds all the long flags (beginning '--') mapped to assignments in this map and its sub maps,
+// to an environment variable named from the given prefix and the flag name, other than the help and show config flags.
+// The name is the upper case prefix and flag, joined with an underscore, with any dashes replaced with underscores.
+// e.g. with a prefix of "restline", --content-type is bound to RESTLINE_CONTENT_TYPE.
+// Keys bound using Env keep their given name and a sub map with its own prefix uses that instead.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) EnvPrefix(prefix string) Commands {
+	c.ensureSettings().envPrefix = prefix
+	return c
+}
+
+// envName gets the name of the environment variable bound to the given key.
+// prefix is the prefix in effect for this map. returns an empty string if the key is not bound.
+func (c Commands) envName(key, prefix string) string {
+	if n, ok := c.settings().env[key]; ok {
+		return n
+	}
+	cmd := c[key]
+	if prefix == "" || !strings.HasPrefix(key, "--") || !c.isAssignment(cmd) || isBuiltinFlag(cmd) {
+		return ""
+	}
+	n := strings.Join([]string{strings.TrimSuffix(prefix, "_"), strings.TrimLeft(key, "-")}, "_")
+	return strings.ToUpper(strings.ReplaceAll(n, "-", "_"))
+}
+
+// envPrefix gets the prefix in effect for this map, being its own prefix or the given prefix of its parent.
+func (c Commands) envPrefix(parent string) string {
+	if p := c.settings().envPrefix; p != "" {
+		return p
+	}
+	return parent
+}
+
+// applyEnv sets the assignments of this map and its sub maps, which are bound to a set environment variable.
+// prefix is the environment prefix of the parent map.
+func (c Commands) applyEnv(prefix string) error {
+	prefix = c.envPrefix(prefix)
+	for _, k := range c.sortedKeys() {
+		cmd := c[k]
+		if c.isSubmap(cmd) {
+			if err := cmd.(Commands).applyEnv(prefix); err != nil {
+				return err
+			}
+			continue
+		}
+		name := c.envName(k, prefix)
+		if name == "" {
+			continue
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := values.SetValue(cmd, v, c.validators(cmd)...); err != nil {
+			return fmt.Errorf("environment variable %s, %v", name, err)
+		}
+		c.recordSource(k, SourceEnv, v, name)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Code generated by helpgen. DO NOT EDIT.
+
+package main
+
+import "github.com/eurozulu/commandgo/help"
+
+func init() {
+	help.HelpLibrary = append(help.HelpLibrary,
+		&help.HelpSubject{
+			Name: "main",
+			Comment: `ShowAbout gives version and copyright information about the application
+A simple local function invoked on the root command map. (Also default, no arguments mapping)
+Uses the Verbose flag to show full copyright data when true.`,
+			HelpItems: []*help.HelpItem{
+				{Key: "--verbose", Comment: "Verbose, when true, displays additional information about the operation."},
+				{Key: "version", Comment: `ShowAbout gives version and copyright information about the application
+A simple local function invoked on the root command map. (Also default, no arguments mapping)
+Uses the Verbose flag to show full copyright data when true.`},
+				{Key: "get", Comment: `Get performs a HTTP GET operation on the given url, appending and given parameters to the url
+The request is aborted should the given context be cancelled.`},
+				{Key: "post", Comment: `Post performs a http POST to the given URL, posting the given data
+u URL must be a valid http(s) URL
+data The data to post
+returns the status code and any response body or error
+The request is aborted should the given context be cancelled.`},
+			},
+		},
+		&help.HelpSubject{
+			Name: "get",
+			Comment: `Get performs a HTTP GET operation on the given url, appending and given parameters to the url
+The request is aborted should the given context be cancelled.`,
+			HelpItems: []*help.HelpItem{
+				{Key: "local", Comment: "GetLocal will retrieve a local file from the given path"},
+				{Key: "--headers", Comment: `ShowHeaders, when true will display the response headers before the content
+Also shows headers if Verbose is true.`},
+			},
+		},
+		&help.HelpSubject{
+			Name: "post",
+			Comment: `Post performs a http POST to the given URL, posting the given data
+u URL must be a valid http(s) URL
+data The data to post
+returns the status code and any response body or error
+The request is aborted should the given context be cancelled.`,
+			HelpItems: []*help.HelpItem{
+				{Key: "local", Comment: `PostLocal performs a local file save on the given data.
+fn File path where to write the given data
+data the data to write
+returns an error if failed to write
+use LocalFilePermissions to set a specific permission on the file, otherwise 0644 is used.`},
+				{Key: "--content-type", Comment: "ContentType defines the format of the data being posted"},
+				{Key: "--permissions", Comment: "LocalFilePermissions sets the file permissions of the local file when 'local' post is performed."},
+			},
+		},
+	)
+}
@@ -15,10 +15,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/eurozulu/commandgo"
+	"github.com/eurozulu/commandgo/completion"
 	"github.com/eurozulu/commandgo/examples/restline/restutils"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 // Sample data for additional info using ShowAbout. (To demo the Verbose flag usage)
@@ -37,6 +43,9 @@ See the License for the specific language governing permissions and
 limitations under the License.
 `
 
+// The help of the commands is generated from the comments of the functions and fields they are mapped to.
+//go:generate go run github.com/eurozulu/commandgo/cmd/helpgen
+
 func main() {
 	// These are our application model objects we will be mapping into
 	var g = &restutils.URLGet{LocalFileRoot: "./content"}
@@ -45,7 +54,6 @@ func main() {
 	// top level flags and commands, available on all commands, usually map to global variables and functions
 	var cmds = commandgo.Commands{
 		"--verbose": &restutils.Verbose,
-		"-v":        &restutils.Verbose,
 		"version":   ShowAbout,
 
 		// Default mapping to show about.  Invoked when no arguments are given
@@ -54,30 +62,34 @@ func main() {
 		// map the 'get' command in subcommand so it has its own flags, seperate from the global flags.
 		// maps to the URLGet instance (g), using default "" mapping to the Get method.
 		// Additional command "get local ..." maps to a second method on the same instance.
-		// Has a single assignment flag to show response headers, mapped twice for a short and long name.
+		// Has a single assignment flag to show response headers, with a short alias.
 		"get": commandgo.Commands{
 			"":          g.Get,
 			"local":     g.GetLocal,
 			"--headers": &g.ShowHeaders,
-			"-I":        &g.ShowHeaders,
-		},
+		}.Alias("--headers", "-I").
+			CompleteParam("local", 0, localFiles(g.LocalFileRoot)),
 
 		// map the post command to the URLPost instance (p), using default "" on Post method.
-		// Has two assignment flags mapped to multiple names, ContentType and LocalFilePermissions
+		// Has two assignment flags with aliases, ContentType and LocalFilePermissions
 		"post": commandgo.Commands{
 			"":               p.Post,
 			"local":          p.PostLocal,
 			"--content-type": &p.ContentType,
-			"--contenttype":  &p.ContentType,
-			"-ct":            &p.ContentType,
 			"--permissions":  &p.LocalFilePermissions,
-			"--perm":         &p.LocalFilePermissions,
-			"-p":             &p.LocalFilePermissions,
-		},
+		}.Alias("--content-type", "--contenttype", "-ct").
+			Alias("--permissions", "--perm", "-p"),
 	}
+	cmds.Alias("--verbose", "-v")
+
+	// allow single letter flags to be clustered, e.g. "get -vI http://..."
+	cmds.ClusterFlags(true)
+
+	// long flags can also be set from the environment, e.g. RESTLINE_CONTENT_TYPE=application/json
+	cmds.EnvPrefix("RESTLINE")
 
-	// Call using the os.CommandLine argument
-	r, err := cmds.RunArgs()
+	// Call using the os.CommandLine argument, cancelling any request in progress on ctrl-c
+	r, err := cmds.RunContext(context.Background(), os.Args[1:]...)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -88,6 +100,29 @@ func main() {
 	}
 }
 
+// localFiles completes the names of the files in the given root directory, for the 'get local' command.
+func localFiles(root string) completion.Provider {
+	return func(token string, flags map[string][]string) []completion.Candidate {
+		dir, name := filepath.Split(token)
+		infos, err := ioutil.ReadDir(filepath.Join(root, dir))
+		if err != nil {
+			return nil
+		}
+		var candidates []completion.Candidate
+		for _, fi := range infos {
+			if !strings.HasPrefix(fi.Name(), name) {
+				continue
+			}
+			value := dir + fi.Name()
+			if fi.IsDir() {
+				value += string(filepath.Separator)
+			}
+			candidates = append(candidates, completion.Candidate{Value: value, Description: fi.Mode().String()})
+		}
+		return candidates
+	}
+}
+
 // ShowAbout gives version and copyright information about the application
 // A simple local function invoked on the root command map. (Also default, no arguments mapping)
 // Uses the Verbose flag to show full copyright data when true.
@@ -4,6 +4,7 @@ package restutils
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -43,8 +44,13 @@ type URLPost struct {
 }
 
 // Get performs a HTTP GET operation on the given url, appending and given parameters to the url
-func (g *URLGet) Get(u *url.URL, params ...string) (string, error) {
-	r, err := http.Get(u.String())
+// The request is aborted should the given context be cancelled.
+func (g *URLGet) Get(ctx context.Context, u *url.URL, params ...string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	r, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", err
 	}
@@ -96,8 +102,14 @@ func (g *URLGet) GetLocal(fn string) (string, error) {
 // u URL must be a valid http(s) URL
 // data The data to post
 // returns the status code and any response body or error
-func (p *URLPost) Post(u *url.URL, data string) (int, string, error) {
-	r, err := http.Post(u.String(), p.ContentType, strings.NewReader(data))
+// The request is aborted should the given context be cancelled.
+func (p *URLPost) Post(ctx context.Context, u *url.URL, data string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(data))
+	if err != nil {
+		return http.StatusInternalServerError, "", err
+	}
+	req.Header.Set("Content-Type", p.ContentType)
+	r, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return http.StatusInternalServerError, "", err
 	}
@@ -15,6 +15,8 @@
 package functions
 
 import (
+	"context"
+	"fmt"
 	"github.com/eurozulu/commandgo/values"
 	"reflect"
 	"runtime"
@@ -50,16 +52,44 @@ func IsMethod(i interface{}) bool {
 	return true
 }
 
+// Caller calls functions using arguments parsed from the command line.
+// Context is given to functions with a leading context.Context parameter. When nil, a background context is given.
+// Validators check the parsed parameters before the function is called, keyed by the position of the parameter, starting at zero.
+// The validators of a variadic parameter check each of its arguments.
+// Inject are values given to parameters of the exact type they are keyed by, in preference to the registered injected types.
+type Caller struct {
+	Context    context.Context
+	Validators map[int][]values.Validator
+	Inject     map[reflect.Type]interface{}
+}
+
 // CallFunc calls the given function interface using the given arguments.
 // interface must be a function (IsFunc returns true).
 // function is called as a global function, assuming all parameters are inputs.
 // If called with a method, will assume the receiver structure is a parameter.
 func CallFunc(i interface{}, args ...string) ([]interface{}, error) {
-	sig := NewSignature(i)
+	return Caller{}.Call(i, args...)
+}
+
+// Call calls the given function interface using the given arguments, as with CallFunc,
+// checking the parsed parameters with the Callers validators.
+func (c Caller) Call(i interface{}, args ...string) ([]interface{}, error) {
+	sig := c.Signature(i)
 	inVals, err := ParseParameters(sig, args)
 	if err != nil {
 		return nil, err
 	}
+	if err := c.validate(sig, inVals); err != nil {
+		return nil, err
+	}
+	inVals = sig.injectParameters(inVals, c.Inject)
+	if sig.HasContext {
+		ctx := c.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		inVals = append([]reflect.Value{reflect.ValueOf(&ctx).Elem()}, inVals...)
+	}
 	outVals := reflect.ValueOf(i).Call(inVals)
 
 	// check if an error returned
@@ -78,6 +108,33 @@ func CallFunc(i interface{}, args ...string) ([]interface{}, error) {
 	return vals, err
 }
 
+// Signature creates the Signature of the given function, as called by this Caller, injecting the types of its Inject values.
+func (c Caller) Signature(i interface{}) *Signature {
+	var injected []reflect.Type
+	for t := range c.Inject {
+		injected = append(injected, t)
+	}
+	return NewSignature(i, injected...)
+}
+
+// validate checks the given parameter values with the validators for their position
+func (c Caller) validate(sig *Signature, inVals []reflect.Value) error {
+	if len(c.Validators) == 0 {
+		return nil
+	}
+	last := len(sig.ParamTypes) - 1
+	for index, v := range inVals {
+		pos := index
+		if sig.IsVariadic && pos > last {
+			pos = last
+		}
+		if err := values.Validate(v.Interface(), c.Validators[pos]...); err != nil {
+			return fmt.Errorf("argument %d, %v", index+1, err)
+		}
+	}
+	return nil
+}
+
 // Get the function name if the given interface is a func.
 // If not a func or is nil, , returns empty string
 // withPackage flag, when true privades a dot delmited <package>.<name>
@@ -0,0 +1,58 @@
+package functions
+
+import (
+	"io"
+	"log"
+	"os"
+	"reflect"
+)
+
+// Injector supplies the value of an injected parameter, each time a function with a parameter of its type is called.
+type Injector func() interface{}
+
+var injectedTypes = map[reflect.Type]Injector{}
+
+// NewInjectedType registers the given type as injected, so parameters of that exact type are supplied by the given Injector,
+// rather than parsed from the arguments.
+// to remove a mapping, add the type with a nil value.
+func NewInjectedType(t reflect.Type, inj Injector) {
+	if inj == nil {
+		delete(injectedTypes, t)
+		return
+	}
+	injectedTypes[t] = inj
+}
+
+// IsInjectedType checks if the given type has been registered as an injected type
+func IsInjectedType(t reflect.Type) bool {
+	_, ok := injectedTypes[t]
+	return ok
+}
+
+// init registers the "out of the box" injected types
+// Only the exact io.Reader type is given stdin, other types the *os.File custom type is assignable to, such as io.ReadCloser, are parsed as a file name.
+func init() {
+	NewInjectedType(reflect.TypeOf((*io.Writer)(nil)).Elem(), func() interface{} {
+		return os.Stdout
+	})
+	NewInjectedType(reflect.TypeOf((*io.Reader)(nil)).Elem(), func() interface{} {
+		return os.Stdin
+	})
+	NewInjectedType(reflect.TypeOf((*log.Logger)(nil)), func() interface{} {
+		return log.Default()
+	})
+}
+
+// injectedValue gets the value to inject for the given type, preferring the given values over the registered Injectors.
+func injectedValue(t reflect.Type, values map[reflect.Type]interface{}) reflect.Value {
+	v, ok := values[t]
+	if !ok {
+		if inj, ok := injectedTypes[t]; ok {
+			v = inj()
+		}
+	}
+	if v == nil {
+		return reflect.Zero(t)
+	}
+	return reflect.ValueOf(v)
+}
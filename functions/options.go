@@ -0,0 +1,124 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// OptionsTag is the struct tag naming the flag of a field, in the form `cmd:"name,short,help"`
+// It is read from the fields of options types and of structs mapped with commandgo.FromStruct.
+const OptionsTag = "cmd"
+
+var optionsTypes = map[reflect.Type]bool{}
+
+// Option is a field of an options type, exposed as a flag.
+// Name is the long flag of the field, Short its single letter flag, if it has one.
+// Index is the index of the field in its struct, as used by reflect.Value.FieldByIndex.
+type Option struct {
+	Name  string
+	Short string
+	Help  string
+	Index []int
+	Type  reflect.Type
+}
+
+// NewOptionsType registers the given struct type, or pointer to a struct, as an options type.
+// Parameters of an options type are injected with a value whose fields are set from the flags of the command line, rather than parsed from a single argument.
+// See Options for how the fields are named.
+// panics if the type is not a struct or pointer to a struct.
+func NewOptionsType(t reflect.Type) {
+	if optionsStruct(t).Kind() != reflect.Struct {
+		panic(fmt.Sprintf("options type %s is not a struct", t.String()))
+	}
+	optionsTypes[t] = true
+}
+
+// RemoveOptionsType removes the given type as an options type.
+func RemoveOptionsType(t reflect.Type) {
+	delete(optionsTypes, t)
+}
+
+// IsOptionsType checks if the given type has been registered as an options type.
+func IsOptionsType(t reflect.Type) bool {
+	return optionsTypes[t]
+}
+
+// Options gets the fields of the given options type which are exposed as flags.
+// All exported fields are exposed, with their name given by the `cmd:"name,short,help"` tag.
+// When no name is given in the tag, the field name in kebab case is used. e.g. DryRun is --dry-run.
+// A tag of "-" hides the field.
+func Options(t reflect.Type) []Option {
+	st := optionsStruct(t)
+	var opts []Option
+	for i := 0; i < st.NumField(); i++ {
+		if opt, ok := FieldOption(st.Field(i)); ok {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
+
+// FieldOption gets the flag of the given struct field, named by its `cmd:"name,short,help"` tag.
+// When no name is given in the tag, the field name in kebab case is used.
+// returns false if the field is not exported or is tagged "-".
+func FieldOption(f reflect.StructField) (Option, bool) {
+	tag := f.Tag.Get(OptionsTag)
+	if f.PkgPath != "" || tag == "-" {
+		return Option{}, false
+	}
+	tags := strings.SplitN(tag, ",", 3)
+	for len(tags) < 3 {
+		tags = append(tags, "")
+	}
+	opt := Option{
+		Name:  "--" + strings.TrimLeft(strings.TrimSpace(tags[0]), "-"),
+		Short: strings.TrimLeft(strings.TrimSpace(tags[1]), "-"),
+		Help:  strings.TrimSpace(tags[2]),
+		Index: f.Index,
+		Type:  f.Type,
+	}
+	if opt.Name == "--" {
+		opt.Name = "--" + KebabCase(f.Name)
+	}
+	if opt.Short != "" {
+		opt.Short = "-" + opt.Short
+	}
+	return opt, true
+}
+
+// NewOptions creates a new, empty value of the given options type.
+// returns the value and a pointer to the struct, through which its fields can be set.
+func NewOptions(t reflect.Type) (value, ptr reflect.Value) {
+	ptr = reflect.New(optionsStruct(t))
+	if t.Kind() == reflect.Ptr {
+		return ptr, ptr
+	}
+	return ptr.Elem(), ptr
+}
+
+// KebabCase converts the given Go style name into lower case words, separated with dashes. e.g. ContentType is content-type.
+// Runs of capitals are kept as one word, so ShowHTTPHeaders is show-http-headers.
+func KebabCase(name string) string {
+	rs := []rune(name)
+	var sb strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1])
+			nextLower := i+1 < len(rs) && unicode.IsLower(rs[i+1])
+			if prevLower || (unicode.IsUpper(rs[i-1]) && nextLower) {
+				sb.WriteRune('-')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+func optionsStruct(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
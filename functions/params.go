@@ -16,6 +16,7 @@ package functions
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/eurozulu/commandgo/values"
 	"reflect"
@@ -72,10 +73,20 @@ func variadicParams(args []string, t reflect.Type) ([]reflect.Value, error) {
 }
 
 // Signature represents the signature of a method or func, both its parameters and its return types.
+// ParamTypes are the parameters supplied by the arguments.
+// HasContext is true when the first parameter is a context.Context.
+// InjectedTypes are the parameters of an injected type, in the order they appear.
+// Neither the context or injected parameters are included in the ParamTypes, as they are given by the caller,
+// rather than parsed from the arguments.
 type Signature struct {
-	ParamTypes  []reflect.Type
-	ReturnTypes []reflect.Type
-	IsVariadic  bool
+	ParamTypes    []reflect.Type
+	ReturnTypes   []reflect.Type
+	IsVariadic    bool
+	HasContext    bool
+	InjectedTypes []reflect.Type
+
+	// injected are the injected parameter types, keyed by their position, following any context.
+	injected map[int]reflect.Type
 }
 
 func (s Signature) String() string {
@@ -97,9 +108,14 @@ func (s Signature) listTypes(t []reflect.Type) string {
 	return bf.String()
 }
 
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
 // NewSignature creates a Signature of the given func or Name
+// Parameters of a registered injected type (See NewInjectedType), options type (See NewOptionsType)
+// or any of the given injected types are injected,
+// with the exception of a variadic parameter.
 // panics if given interface is not a function or method
-func NewSignature(i interface{}) *Signature {
+func NewSignature(i interface{}, injected ...reflect.Type) *Signature {
 	if !IsFunc(i) {
 		panic("Not a function")
 	}
@@ -112,8 +128,21 @@ func NewSignature(i interface{}) *Signature {
 		index++
 	}
 	in := t.NumIn()
-	for ; index < in; index++ {
-		params = append(params, t.In(index))
+	hasContext := index < in && t.In(index) == contextType
+	if hasContext {
+		index++
+	}
+	var injectedTypes []reflect.Type
+	injectedPos := map[int]reflect.Type{}
+	for pos := 0; index < in; index++ {
+		pt := t.In(index)
+		if !(t.IsVariadic() && index == in-1) && isInjected(pt, injected) {
+			injectedTypes = append(injectedTypes, pt)
+			injectedPos[pos] = pt
+		} else {
+			params = append(params, pt)
+		}
+		pos++
 	}
 	out := t.NumOut()
 	returns := make([]reflect.Type, out)
@@ -121,8 +150,49 @@ func NewSignature(i interface{}) *Signature {
 		returns[i] = t.Out(i)
 	}
 	return &Signature{
-		ParamTypes:  params,
-		ReturnTypes: returns,
-		IsVariadic:  t.IsVariadic(),
+		ParamTypes:    params,
+		ReturnTypes:   returns,
+		IsVariadic:    t.IsVariadic(),
+		HasContext:    hasContext,
+		InjectedTypes: injectedTypes,
+		injected:      injectedPos,
+	}
+}
+
+// isInjected checks if the given type is a registered injected or options type, or one of the given types
+func isInjected(t reflect.Type, injected []reflect.Type) bool {
+	if IsInjectedType(t) || IsOptionsType(t) {
+		return true
+	}
+	for _, it := range injected {
+		if it == t {
+			return true
+		}
+	}
+	return false
+}
+
+// injectParameters merges the given parsed parameter values with the values of the injected parameters, in the order of the signature.
+// values are injected in preference to those of the registered injected types.
+func (s Signature) injectParameters(parsed []reflect.Value, values map[reflect.Type]interface{}) []reflect.Value {
+	if len(s.injected) == 0 {
+		return parsed
+	}
+	vals := make([]reflect.Value, 0, len(parsed)+len(s.injected))
+	for pos := 0; pos < len(s.ParamTypes)+len(s.injected); pos++ {
+		if t, ok := s.injected[pos]; ok {
+			vals = append(vals, injectedValue(t, values))
+			continue
+		}
+		if len(parsed) == 0 {
+			break
+		}
+		if s.IsVariadic && pos == len(s.ParamTypes)+len(s.injected)-1 {
+			// final variadic parameter takes all that remain
+			break
+		}
+		vals = append(vals, parsed[0])
+		parsed = parsed[1:]
 	}
+	return append(vals, parsed...)
 }
@@ -0,0 +1,281 @@
+package commandgo
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/eurozulu/commandgo/functions"
+	"github.com/eurozulu/commandgo/help"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// annotatedHelp copies the subjects of the help library, adding the details known about the keys of this map, and its sub maps,
+// to the items of the subject of each map. The help library itself is left unchanged.
+func (c Commands) annotatedHelp() []*help.HelpSubject {
+	lib := make([]*help.HelpSubject, len(help.HelpLibrary))
+	copy(lib, help.HelpLibrary)
+	c.annotateHelp(lib, nil, "")
+	return lib
+}
+
+// annotateHelp replaces the subject of this map, at the given path of keys, in the given library with an annotated copy,
+// followed by the subjects of its sub maps.
+// envPrefix is the environment prefix of the parent map.
+func (c Commands) annotateHelp(lib []*help.HelpSubject, path []string, envPrefix string) {
+	envPrefix = c.envPrefix(envPrefix)
+	name := subjectName(path)
+	for i, hs := range lib {
+		if strings.EqualFold(hs.Name, name) {
+			lib[i] = c.annotateSubject(hs, envPrefix)
+		}
+	}
+	for _, k := range c.sortedKeys() {
+		if cmd := c[k]; c.isSubmap(cmd) {
+			cmd.(Commands).annotateHelp(lib, append(append([]string{}, path...), k), envPrefix)
+		}
+	}
+}
+
+// annotateSubject copies the given subject, adding the details known about the keys of this map to copies of their items.
+func (c Commands) annotateSubject(hs *help.HelpSubject, envPrefix string) *help.HelpSubject {
+	as := *hs
+	as.HelpItems = make([]*help.HelpItem, len(hs.HelpItems))
+	for i, li := range hs.HelpItems {
+		hi := *li
+		as.HelpItems[i] = &hi
+		k, ok := c.findKey(hi.Key)
+		if !ok || !strings.EqualFold(hi.Key, k) || c.isSubmap(c[k]) {
+			continue
+		}
+		gi := c.helpItem(k, envPrefix)
+		hi.Aliases = gi.Aliases
+		hi.Negation = gi.Negation
+		hi.Env = gi.Env
+		hi.Constraints = gi.Constraints
+		hi.Choices = gi.Choices
+		if hi.Comment == "" {
+			hi.Comment = gi.Comment
+		}
+		if hi.Usage == "" {
+			hi.Usage = gi.Usage
+		}
+		if hi.Default == "" {
+			hi.Default = gi.Default
+		}
+	}
+	return &as
+}
+
+// subjectName gets the name of the help subject of the map at the given path of keys.
+// The root map is the "main" subject, sub maps are named by the keys leading to them, delimited by a space.
+func subjectName(path []string) string {
+	if len(path) == 0 {
+		return "main"
+	}
+	return strings.Join(path, " ")
+}
+
+// builtinComments are the help text of the flags added by the framework
+var builtinComments = map[string]string{
+	help.HelpFlagFull:  "shows this help",
+	help.HelpFlagShort: "shows this help",
+	ShowConfigFlag:     "shows the value of each assignment and where it was set, rather than running the command",
+}
+
+// HelpSubjects generates the help of this map and its sub maps, from their keys and the commands they are mapped to.
+// Each map has a subject, the subject of this map being named "main" and those of sub maps by the keys leading to them, delimited by a space.
+// Keys mapped to the same assignment, along with their aliases, are grouped into a single item.
+// Items are described by their help text, the usage of their function or value type and their current value, as a default.
+func (c Commands) HelpSubjects() []*help.HelpSubject {
+	return c.helpSubjects(nil, "")
+}
+
+// helpSubjects generates the subjects of this map, at the given path of keys, and its sub maps.
+// envPrefix is the environment prefix of the parent map.
+func (c Commands) helpSubjects(path []string, envPrefix string) []*help.HelpSubject {
+	envPrefix = c.envPrefix(envPrefix)
+	hs := &help.HelpSubject{Name: subjectName(path), Comment: c.usage(path)}
+	var subjects []*help.HelpSubject
+	for _, key := range c.groupedKeys() {
+		cmd := c[key]
+		if c.isSubmap(cmd) {
+			subjects = append(subjects, cmd.(Commands).helpSubjects(append(append([]string{}, path...), key), envPrefix)...)
+		}
+		if key == "" {
+			// the default mapping is described by the usage of the subject
+			continue
+		}
+		hs.HelpItems = append(hs.HelpItems, c.subjectItem(hs.Name, key, envPrefix))
+		hs.HelpItems = append(hs.HelpItems, c.optionsItems(key)...)
+	}
+	return append([]*help.HelpSubject{hs}, subjects...)
+}
+
+// groupedKeys gets the keys of this map, in name order, less those grouped with another.
+// Keys mapped to the same assignment are grouped, along with aliases, under their canonical key.
+func (c Commands) groupedKeys() []string {
+	var keys []string
+	grouped := map[string]bool{}
+	for _, k := range c.sortedKeys() {
+		if grouped[k] {
+			continue
+		}
+		key := c.canonicalKey(k)
+		grouped[key] = true
+		for _, al := range c.Aliases(key) {
+			grouped[al] = true
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// subjectItem generates the item of the given key, described by its item in the library subject of the given name, when it has one.
+// A comment given to the key is used over that of the library.
+func (c Commands) subjectItem(subject, key, envPrefix string) *help.HelpItem {
+	hi := c.helpItem(key, envPrefix)
+	if _, ok := c.settings().comments[key]; ok {
+		return hi
+	}
+	if hs := help.FindSubject(subject); hs != nil {
+		if li := hs.Item(key); li != nil && li.Comment != "" {
+			hi.Comment = li.Comment
+		}
+	}
+	return hi
+}
+
+// helpItem generates the item of the given key
+func (c Commands) helpItem(key, envPrefix string) *help.HelpItem {
+	cmd := c[key]
+	hi := &help.HelpItem{
+		Key:         key,
+		Aliases:     c.Aliases(key),
+		Comment:     c.description(key),
+		Env:         c.envName(key, envPrefix),
+		Constraints: c.constraintsOf(key),
+	}
+	if hi.Comment == "" {
+		hi.Comment = builtinComments[key]
+	}
+	if c.isNegatable(key) {
+		hi.Negation = negatedKey(key)
+	}
+	switch {
+	case c.isSubmap(cmd):
+		hi.Usage = "<command>"
+	case functions.IsFunc(cmd):
+		hi.Usage = c.funcUsage(key)
+	case c.isAssignment(cmd):
+		hi.Choices = values.EnumChoices(reflect.TypeOf(cmd))
+		if !values.IsKind(cmd, reflect.Bool) && !c.isCounter(key) {
+			hi.Usage = typeUsage(reflect.TypeOf(cmd).Elem())
+		}
+		if !reflect.ValueOf(cmd).Elem().IsZero() && !isBuiltinFlag(cmd) {
+			hi.Default = values.FormatValue(cmd)
+		}
+	}
+	return hi
+}
+
+// optionsItems generates the items of the flags of any options parameters of the function mapped to the given key.
+func (c Commands) optionsItems(key string) []*help.HelpItem {
+	cmd := c[key]
+	if !functions.IsFunc(cmd) {
+		return nil
+	}
+	var items []*help.HelpItem
+	for _, t := range c.caller(context.Background(), key).Signature(cmd).InjectedTypes {
+		if !functions.IsOptionsType(t) {
+			continue
+		}
+		for _, opt := range functions.Options(t) {
+			hi := &help.HelpItem{Key: opt.Name, Comment: opt.Help}
+			if opt.Short != "" {
+				hi.Aliases = []string{opt.Short}
+			}
+			if opt.Type.Kind() != reflect.Bool {
+				hi.Usage = typeUsage(opt.Type)
+			}
+			hi.Comment = strings.TrimSpace(strings.Join([]string{hi.Comment, fmt.Sprintf("(%s option)", key)}, " "))
+			items = append(items, hi)
+		}
+	}
+	return items
+}
+
+// canonicalKey gets the key which represents the group of keys mapped to the same assignment as the given key.
+// Long flags are preferred over short, then the longest, then the first in name order.
+func (c Commands) canonicalKey(key string) string {
+	if !c.isAssignment(c[key]) {
+		return key
+	}
+	canon := key
+	for _, k := range c.Aliases(key) {
+		if _, ok := c[k]; !ok {
+			continue
+		}
+		lk, lc := strings.HasPrefix(k, "--"), strings.HasPrefix(canon, "--")
+		switch {
+		case lk != lc:
+			if lk {
+				canon = k
+			}
+		case len(k) != len(canon):
+			if len(k) > len(canon) {
+				canon = k
+			}
+		case k < canon:
+			canon = k
+		}
+	}
+	return canon
+}
+
+// usage describes how this map is used, at the given path of keys.
+func (c Commands) usage(path []string) string {
+	prog := strings.Join(append([]string{filepath.Base(os.Args[0])}, path...), " ")
+	var lines []string
+	for _, s := range c.synopsis() {
+		lines = append(lines, fmt.Sprintf("usage: %s %s", prog, s))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// synopsis gets the ways this map is used, following the keys leading to it. e.g. "[flags] <command>"
+func (c Commands) synopsis() []string {
+	var lines []string
+	if len(c.commandCandidates()) > 0 {
+		lines = append(lines, "[flags] <command>")
+	}
+	if k, ok := c[""]; ok && functions.IsFunc(k) {
+		lines = append(lines, strings.TrimSpace(fmt.Sprintf("[flags] %s", c.funcUsage(""))))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "[flags]")
+	}
+	return lines
+}
+
+// funcUsage describes the parameters of the function mapped to the given key.
+func (c Commands) funcUsage(key string) string {
+	sig := c.caller(context.Background(), key).Signature(c[key])
+	var params []string
+	for i, pt := range sig.ParamTypes {
+		if sig.IsVariadic && i == len(sig.ParamTypes)-1 {
+			params = append(params, fmt.Sprintf("[%s...]", typeUsage(pt.Elem())))
+			continue
+		}
+		params = append(params, typeUsage(pt))
+	}
+	return strings.Join(params, " ")
+}
+
+// typeUsage describes a value of the given type. e.g. <int>
+func typeUsage(t reflect.Type) string {
+	return fmt.Sprintf("<%s>", strings.TrimLeft(t.String(), "*"))
+}
@@ -20,13 +20,42 @@ var HelpRequested bool
 // the given name may be a subject name, command or flag.
 // returns the specific text for which ever is found matching the given name.
 func ShowHelp(cmd string, args ...string) []interface{} {
-	hs, hi := findSubject(cmd)
-	if hs == nil {
-		hs, hi = findSubject(args[0])
+	return ShowLibraryHelp(HelpLibrary, nil, cmd, args...)
+}
+
+// ShowLibraryHelp shows the help, as ShowHelp, found in the given library in place of the HelpLibrary.
+// generate, when not nil, generates the subjects used when no subject or item is found in the library.
+func ShowLibraryHelp(lib []*HelpSubject, generate func() []*HelpSubject, cmd string, args ...string) []interface{} {
+	libs := [][]*HelpSubject{lib}
+	if generate != nil {
+		libs = append(libs, generate())
+	}
+	var hs *HelpSubject
+	var hi *HelpItem
+	names := []string{cmd}
+	if len(args) > 0 {
+		names = append(names, args[0])
 	}
-	if hs == nil {
+	for _, name := range names {
+		if hs != nil {
+			break
+		}
+		if name == HelpFlagShort || name == HelpFlagFull {
+			// help for the help flag itself is never the subject
+			continue
+		}
+		for _, lib := range libs {
+			if hs, hi = findSubject(lib, name); hs != nil {
+				break
+			}
+		}
+	}
+	for _, lib := range libs {
+		if hs != nil {
+			break
+		}
 		// no matching help subject found, display root help
-		hs, _ = findSubject("main")
+		hs, _ = findSubject(lib, "main")
 	}
 
 	var result []interface{}
@@ -43,12 +72,34 @@ func ShowHelp(cmd string, args ...string) []interface{} {
 	return result
 }
 
-func findSubject(name string) (*HelpSubject, *HelpItem) {
+// FindItem finds the HelpItem in the HelpLibrary, with the given name.
+// returns nil if no item is found.
+func FindItem(name string) *HelpItem {
+	_, hi := findSubject(HelpLibrary, name)
+	return hi
+}
+
+// FindSubject finds the HelpSubject in the HelpLibrary, with the given name.
+// returns nil if no subject is found.
+func FindSubject(name string) *HelpSubject {
 	for _, hs := range HelpLibrary {
 		if strings.EqualFold(hs.Name, name) {
-			return hs, nil
+			return hs
 		}
+	}
+	return nil
+}
 
+// findSubject finds the subject in the given library with the given name, or containing an item with the given name.
+// Subject names are matched, in all the subjects, before item names, so a sub command finds its own subject
+// rather than its item in the subject of the parent map.
+func findSubject(lib []*HelpSubject, name string) (*HelpSubject, *HelpItem) {
+	for _, hs := range lib {
+		if strings.EqualFold(hs.Name, name) {
+			return hs, nil
+		}
+	}
+	for _, hs := range lib {
 		hi := findItem(name, hs.HelpItems)
 		if hi != nil {
 			return hs, hi
@@ -0,0 +1,44 @@
+package help
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFindSubject(t *testing.T) {
+	lib := []*HelpSubject{
+		{Name: "main", HelpItems: []*HelpItem{{Key: "remote", Comment: "manages remotes"}, {Key: "--verbose"}}},
+		{Name: "remote", Comment: "usage: app remote [flags] <command>", HelpItems: []*HelpItem{{Key: "add"}}},
+	}
+	hs, hi := findSubject(lib, "remote")
+	if hs != lib[1] || hi != nil {
+		t.Fatalf("expected subject name matched before item of another subject, found %v, %v", hs, hi)
+	}
+	hs, hi = findSubject(lib, "--Verbose")
+	if hs != lib[0] || hi == nil || hi.Key != "--verbose" {
+		t.Fatalf("expected item of main subject, found %v, %v", hs, hi)
+	}
+	if hs, hi = findSubject(lib, "missing"); hs != nil || hi != nil {
+		t.Fatalf("expected nothing found, found %v, %v", hs, hi)
+	}
+}
+
+func TestShowLibraryHelp(t *testing.T) {
+	lib := []*HelpSubject{{Name: "main", HelpItems: []*HelpItem{{Key: "--verbose", Comment: "shows more"}}}}
+	var generated bool
+	generate := func() []*HelpSubject {
+		generated = true
+		return []*HelpSubject{{Name: "remote", HelpItems: []*HelpItem{{Key: "add", Comment: "adds a remote"}}}}
+	}
+	out := ShowLibraryHelp(lib, generate, "remote", HelpFlagFull)
+	if !generated || len(out) != 1 || !strings.Contains(out[0].(string), "adds a remote") {
+		t.Fatalf("expected generated subject, found %v", out)
+	}
+	out = ShowLibraryHelp(lib, generate, "", HelpFlagFull)
+	if len(out) != 1 || !strings.Contains(out[0].(string), "shows more") {
+		t.Fatalf("expected library main subject, found %v", out)
+	}
+	if out := ShowLibraryHelp(lib, nil, "remote"); len(out) != 1 || strings.Contains(out[0].(string), "adds a remote") {
+		t.Fatalf("expected main subject without generator, found %v", out)
+	}
+}
@@ -10,10 +10,22 @@ import (
 // Key is its principle name, the name by which this item is referred to.
 // Aliases are other names the same item is known by
 // Comment is the known information about the item.
+// Negation is the negated form of a bool flag, which sets the flag to false.
+// Env is the name of the environment variable the item is bound to.
+// Constraints describe the rules the item must follow, such as being required.
+// Choices are the values the item accepts, when limited to a set of names.
+// Usage describes the value or parameters the item takes. e.g. <int> or <url.URL> [<string>...]
+// Default is the value of the item when not given.
 type HelpItem struct {
-	Key     string
-	Aliases []string
-	Comment string
+	Key         string
+	Aliases     []string
+	Comment     string
+	Negation    string
+	Env         string
+	Constraints []string
+	Choices     []string
+	Usage       string
+	Default     string
 }
 
 // HelpSubject is a logical collection of HelpItems.
@@ -53,6 +65,12 @@ func (hs HelpSubject) String() string {
 	return fmt.Sprintf("%s%s", t, strings.Join(items, "\n"))
 }
 
+// Item finds the item of the subject with the given name.
+// returns nil if the subject has no item with the name
+func (hs HelpSubject) Item(name string) *HelpItem {
+	return findItem(name, hs.HelpItems)
+}
+
 func (hi HelpItem) IsFlag() bool {
 	return strings.HasPrefix(hi.Key, "-")
 }
@@ -62,7 +80,7 @@ func (hi HelpItem) IsName(name string) bool {
 		return true
 	}
 	for _, k := range hi.Aliases {
-		if strings.EqualFold(k, hi.Key) {
+		if strings.EqualFold(k, name) {
 			return true
 		}
 	}
@@ -70,14 +88,51 @@ func (hi HelpItem) IsName(name string) bool {
 }
 
 func (hi HelpItem) String() string {
-	var als string
-	if len(hi.Aliases) > 0 {
-		als = fmt.Sprintf("\naliases: %s", strings.Join(hi.Aliases, ", "))
+	var env string
+	if hi.Env != "" {
+		env = fmt.Sprintf("\nenvironment: %s", hi.Env)
+	}
+	var cons string
+	if len(hi.Constraints) > 0 {
+		cons = fmt.Sprintf("\nconstraints: %s", strings.Join(hi.Constraints, "; "))
 	}
-	return fmt.Sprintf("%s\t\t%s%s\n", hi.Key, hi.Comment, als)
+	var choices string
+	if len(hi.Choices) > 0 {
+		choices = fmt.Sprintf("\nchoices: %s", strings.Join(hi.Choices, ", "))
+	}
+	var def string
+	if hi.Default != "" {
+		def = fmt.Sprintf("\ndefault: %s", hi.Default)
+	}
+	return fmt.Sprintf("%s\t\t%s%s%s%s%s\n", hi.names(), hi.Comment, env, cons, choices, def)
 }
 
 func (hi HelpItem) StringShort() string {
 	cs := strings.SplitN(hi.Comment, "\n", 2)
-	return fmt.Sprintf("%s\t%s", hi.Key, cs[0])
+	s := fmt.Sprintf("%s\t%s", hi.names(), cs[0])
+	if len(hi.Constraints) > 0 {
+		s = fmt.Sprintf("%s (%s)", s, strings.Join(hi.Constraints, "; "))
+	}
+	if hi.Default != "" {
+		s = fmt.Sprintf("%s (default: %s)", s, hi.Default)
+	}
+	if hi.Env != "" {
+		s = fmt.Sprintf("%s [$%s]", s, hi.Env)
+	}
+	return s
+}
+
+// names gets the key of the item, its aliases and its negation, if it has one, followed by its choices or usage, if it has any.
+func (hi HelpItem) names() string {
+	names := append([]string{hi.Key}, hi.Aliases...)
+	if hi.Negation != "" {
+		names = append(names, hi.Negation)
+	}
+	n := strings.Join(names, ", ")
+	if len(hi.Choices) > 0 {
+		n = fmt.Sprintf("%s {%s}", n, strings.Join(hi.Choices, "|"))
+	} else if hi.Usage != "" {
+		n = fmt.Sprintf("%s %s", n, hi.Usage)
+	}
+	return n
 }
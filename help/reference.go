@@ -0,0 +1,206 @@
+package help
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Reference is the help of a single command map, from which its reference pages are written.
+// Name is the full command of the map, the program name followed by any keys leading to it. e.g. "restline get"
+// Synopsis are the ways the command is used, each following the name. e.g. "[flags] <command>"
+// Description is the known information about the command.
+// Commands are the commands of the map and Options its flags.
+// SeeAlso are the names of the related references, its parent and sub commands.
+type Reference struct {
+	Name        string
+	Synopsis    []string
+	Description string
+	Commands    []*HelpItem
+	Options     []*HelpItem
+	SeeAlso     []string
+}
+
+// FileName gets the name of the file of the reference, with the given extension. e.g. restline-get.1
+func (r Reference) FileName(ext string) string {
+	return fmt.Sprintf("%s.%s", pageName(r.Name), ext)
+}
+
+// ManPage gets the reference as a roff man page, of section 1.
+func (r Reference) ManPage() string {
+	buf := bytes.NewBuffer(nil)
+	prog := strings.SplitN(r.Name, " ", 2)[0]
+	fmt.Fprintf(buf, ".TH \"%s\" \"1\" \"\" \"%s\" \"%s Manual\"\n", strings.ToUpper(roff(pageName(r.Name))), roff(prog), roff(prog))
+	fmt.Fprintln(buf, ".SH NAME")
+	name := roff(pageName(r.Name))
+	if summary := firstLine(r.Description); summary != "" {
+		name = fmt.Sprintf("%s \\- %s", name, roff(summary))
+	}
+	fmt.Fprintln(buf, name)
+
+	fmt.Fprintln(buf, ".SH SYNOPSIS")
+	for i, s := range r.Synopsis {
+		if i > 0 {
+			fmt.Fprintln(buf, ".br")
+		}
+		fmt.Fprintf(buf, ".B %s\n%s\n", roff(r.Name), roff(s))
+	}
+	if r.Description != "" {
+		fmt.Fprintln(buf, ".SH DESCRIPTION")
+		fmt.Fprintln(buf, roffText(r.Description))
+	}
+	manItems(buf, "COMMANDS", r.Commands)
+	manItems(buf, "OPTIONS", r.Options)
+
+	if env := r.environment(); len(env) > 0 {
+		fmt.Fprintln(buf, ".SH ENVIRONMENT")
+		for _, hi := range env {
+			fmt.Fprintf(buf, ".TP\n.B %s\nSets %s\n", roff(hi.Env), roff(hi.Key))
+		}
+	}
+	if len(r.SeeAlso) > 0 {
+		fmt.Fprintln(buf, ".SH SEE ALSO")
+		var refs []string
+		for _, s := range r.SeeAlso {
+			refs = append(refs, fmt.Sprintf(".BR %s (1)", roff(pageName(s))))
+		}
+		fmt.Fprintln(buf, strings.Join(refs, ",\n"))
+	}
+	return buf.String()
+}
+
+// Markdown gets the reference as a Markdown page.
+// Sub commands and related references are linked to the Markdown files of their references.
+func (r Reference) Markdown() string {
+	buf := bytes.NewBuffer(nil)
+	fmt.Fprintf(buf, "# %s\n\n", r.Name)
+	if r.Description != "" {
+		fmt.Fprintf(buf, "%s\n\n", r.Description)
+	}
+	fmt.Fprintln(buf, "## Synopsis")
+	fmt.Fprintln(buf, "```")
+	for _, s := range r.Synopsis {
+		fmt.Fprintf(buf, "%s %s\n", r.Name, s)
+	}
+	fmt.Fprintln(buf, "```")
+
+	if len(r.Commands) > 0 {
+		fmt.Fprintln(buf, "\n## Commands")
+		for _, hi := range r.Commands {
+			names := markdownNames(hi)
+			if r.hasSeeAlso(r.Name + " " + hi.Key) {
+				names = fmt.Sprintf("[%s](%s)", names, Reference{Name: r.Name + " " + hi.Key}.FileName("md"))
+			}
+			fmt.Fprintf(buf, "- %s%s\n", names, markdownComment(hi))
+		}
+	}
+	if len(r.Options) > 0 {
+		fmt.Fprintln(buf, "\n## Options")
+		for _, hi := range r.Options {
+			fmt.Fprintf(buf, "- %s%s\n", markdownNames(hi), markdownComment(hi))
+		}
+	}
+	if env := r.environment(); len(env) > 0 {
+		fmt.Fprintln(buf, "\n## Environment")
+		for _, hi := range env {
+			fmt.Fprintf(buf, "- `%s` sets `%s`\n", hi.Env, hi.Key)
+		}
+	}
+	if len(r.SeeAlso) > 0 {
+		fmt.Fprintln(buf, "\n## See also")
+		for _, s := range r.SeeAlso {
+			fmt.Fprintf(buf, "- [%s](%s)\n", s, Reference{Name: s}.FileName("md"))
+		}
+	}
+	return buf.String()
+}
+
+// environment gets the options bound to an environment variable
+func (r Reference) environment() []*HelpItem {
+	var items []*HelpItem
+	for _, hi := range r.Options {
+		if hi.Env != "" {
+			items = append(items, hi)
+		}
+	}
+	return items
+}
+
+func (r Reference) hasSeeAlso(name string) bool {
+	for _, s := range r.SeeAlso {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// manItems writes the given items as a section of tagged paragraphs
+func manItems(buf *bytes.Buffer, section string, items []*HelpItem) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, ".SH %s\n", section)
+	for _, hi := range items {
+		fmt.Fprintf(buf, ".TP\n.B %s\n", roff(hi.names()))
+		var lines []string
+		if hi.Comment != "" {
+			lines = append(lines, roffText(hi.Comment))
+		}
+		if len(hi.Constraints) > 0 {
+			lines = append(lines, roff(fmt.Sprintf("Constraints: %s", strings.Join(hi.Constraints, "; "))))
+		}
+		if hi.Default != "" {
+			lines = append(lines, roff(fmt.Sprintf("Default: %s", hi.Default)))
+		}
+		fmt.Fprintln(buf, strings.Join(lines, "\n.br\n"))
+	}
+}
+
+// markdownNames gets the names of the item as inline code
+func markdownNames(hi *HelpItem) string {
+	return fmt.Sprintf("`%s`", hi.names())
+}
+
+// markdownComment gets the comment of the item, with its constraints and default, following a dash.
+func markdownComment(hi *HelpItem) string {
+	s := strings.Join(strings.Fields(hi.Comment), " ")
+	if len(hi.Constraints) > 0 {
+		s = strings.TrimSpace(fmt.Sprintf("%s (%s)", s, strings.Join(hi.Constraints, "; ")))
+	}
+	if hi.Default != "" {
+		s = strings.TrimSpace(fmt.Sprintf("%s (default: `%s`)", s, hi.Default))
+	}
+	if s == "" {
+		return ""
+	}
+	return " - " + s
+}
+
+// pageName gets the name of the page of the given command, its words delimited by a dash.
+func pageName(name string) string {
+	return strings.Join(strings.Fields(name), "-")
+}
+
+func firstLine(s string) string {
+	return strings.TrimSpace(strings.SplitN(s, "\n", 2)[0])
+}
+
+// roff escapes the given text for a single line of roff
+func roff(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	s = strings.ReplaceAll(s, "-", "\\-")
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = "\\&" + s
+	}
+	return s
+}
+
+// roffText escapes the given text, keeping each of its lines as a line break
+func roffText(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = roff(strings.TrimSpace(l))
+	}
+	return strings.Join(lines, "\n.br\n")
+}
@@ -0,0 +1,84 @@
+package help
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoff(t *testing.T) {
+	for s, expect := range map[string]string{
+		"plain text":       "plain text",
+		".starts with dot": "\\&.starts with dot",
+		"'starts quoted":   "\\&'starts quoted",
+		"C:\\path\\file":   "C:\\epath\\efile",
+		"--flag-name":      "\\-\\-flag\\-name",
+		"mid. 'sentence'":  "mid. 'sentence'",
+	} {
+		if r := roff(s); r != expect {
+			t.Fatalf("unexpected roff of %q, expected %q, found %q", s, expect, r)
+		}
+	}
+	if r := roffText("  first line\n.second line\n'third\\line  \n"); r != "first line\n.br\n\\&.second line\n.br\n\\&'third\\eline" {
+		t.Fatalf("unexpected roff text, found %q", r)
+	}
+}
+
+func TestReference_ManPage(t *testing.T) {
+	r := Reference{
+		Name:        "app remote",
+		Synopsis:    []string{"[flags] <command>"},
+		Description: "manages remotes\n.hidden is not a request",
+		Commands:    []*HelpItem{{Key: "add", Usage: "<url.URL>", Comment: "adds a remote\n'quoted line"}},
+		Options:     []*HelpItem{{Key: "--root", Comment: "root \\ directory", Env: "APP_ROOT", Default: "/tmp"}},
+		SeeAlso:     []string{"app", "app remote add"},
+	}
+	page := r.ManPage()
+	for _, expect := range []string{
+		".TH \"APP\\-REMOTE\" \"1\" \"\" \"app\" \"app Manual\"\n",
+		".SH NAME\napp\\-remote \\- manages remotes\n",
+		".SH DESCRIPTION\nmanages remotes\n.br\n\\&.hidden is not a request\n",
+		".SH COMMANDS\n.TP\n.B add <url.URL>\nadds a remote\n.br\n\\&'quoted line\n",
+		".SH OPTIONS\n.TP\n.B \\-\\-root\nroot \\e directory\n.br\nDefault: /tmp\n",
+		".SH ENVIRONMENT\n.TP\n.B APP_ROOT\nSets \\-\\-root\n",
+		".SH SEE ALSO\n.BR app (1),\n.BR app\\-remote\\-add (1)\n",
+	} {
+		if !strings.Contains(page, expect) {
+			t.Fatalf("expected %q in man page, found %s", expect, page)
+		}
+	}
+	for _, line := range strings.Split(page, "\n") {
+		if strings.HasPrefix(line, ".hidden") || strings.HasPrefix(line, "'") {
+			t.Fatalf("unescaped control line %q in man page", line)
+		}
+	}
+	if fn := r.FileName("1"); fn != "app-remote.1" {
+		t.Fatalf("unexpected file name, %s", fn)
+	}
+}
+
+func TestReference_Markdown(t *testing.T) {
+	r := Reference{
+		Name:        "app remote",
+		Synopsis:    []string{"[flags] <command>"},
+		Description: "manages remotes",
+		Commands: []*HelpItem{
+			{Key: "add", Usage: "<url.URL>", Comment: "adds a remote\nover two lines"},
+			{Key: "list", Comment: "lists the remotes"},
+		},
+		Options: []*HelpItem{{Key: "--root", Constraints: []string{"required"}, Default: "/tmp"}},
+		SeeAlso: []string{"app", "app remote add"},
+	}
+	page := r.Markdown()
+	for _, expect := range []string{
+		"# app remote\n\nmanages remotes\n",
+		"## Synopsis\n```\napp remote [flags] <command>\n```\n",
+		"- [`add <url.URL>`](app-remote-add.md) - adds a remote over two lines\n",
+		"- `list` - lists the remotes\n",
+		"- `--root` - (required) (default: `/tmp`)\n",
+		"## See also\n- [app](app.md)\n- [app remote add](app-remote-add.md)\n",
+	} {
+		if !strings.Contains(page, expect) {
+			t.Fatalf("expected %q in markdown page, found %s", expect, page)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package commandgo
+
+import (
+	"reflect"
+)
+
+// Inject gives the given values to the parameters of functions mapped in this map, and its sub maps, which are of the exact type of the value.
+// Injected parameters are not parsed from the arguments, allowing functions to be given services, such as a database connection.
+// Values are injected in preference to the types registered with functions.NewInjectedType.
+// A function may also take the Commands map it is mapped in, which is always injected.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Inject(vals ...interface{}) Commands {
+	s := c.ensureSettings()
+	if s.inject == nil {
+		s.inject = map[reflect.Type]interface{}{}
+	}
+	for _, v := range vals {
+		s.inject[reflect.TypeOf(v)] = v
+	}
+	for _, k := range c.sortedKeys() {
+		if cmd := c[k]; c.isSubmap(cmd) {
+			cmd.(Commands).Inject(vals...)
+		}
+	}
+	return c
+}
+
+// injected gets the values injected into the functions of this map, including the map itself.
+func (c Commands) injected() map[reflect.Type]interface{} {
+	inj := map[reflect.Type]interface{}{reflect.TypeOf(c): c}
+	for t, v := range c.settings().inject {
+		inj[t] = v
+	}
+	return inj
+}
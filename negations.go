@@ -0,0 +1,79 @@
+package commandgo
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/eurozulu/commandgo/values"
+)
+
+// NegationPrefix is the prefix given to bool flags to form their negated flag.
+// e.g. a bool flag of --verbose may be set to false using --no-verbose
+const NegationPrefix = "--no-"
+
+// negatedKey gets the negated form of the given key.
+func negatedKey(key string) string {
+	return strings.Join([]string{NegationPrefix, strings.TrimLeft(key, "-")}, "")
+}
+
+// isNegatable checks if the given key is a flag mapped to a bool assignment, so has a negated form.
+// The help and show config flags have no negated form.
+func (c Commands) isNegatable(key string) bool {
+	cmd, ok := c[key]
+	if !ok || !strings.HasPrefix(key, "-") || !c.isAssignment(cmd) || isBuiltinFlag(cmd) {
+		return false
+	}
+	return values.IsKind(cmd, reflect.Bool)
+}
+
+// findNegatedKey finds the bool flag key, which the given argument is the negated form of.
+// An exact match is preferred, otherwise the first key, in sorted order, matching in a case insensitive search.
+func (c Commands) findNegatedKey(arg string) (string, bool) {
+	if !strings.HasPrefix(strings.ToLower(arg), NegationPrefix) {
+		return "", false
+	}
+	names := map[string]string{}
+	for _, k := range c.sortedKeys() {
+		names[k] = k
+	}
+	for al, k := range c.settings().aliases {
+		if _, ok := names[al]; !ok {
+			names[al] = k
+		}
+	}
+	var found []string
+	for n, k := range names {
+		if !c.isNegatable(k) {
+			continue
+		}
+		nk := negatedKey(n)
+		if nk == arg {
+			return k, true
+		}
+		if strings.EqualFold(nk, arg) {
+			found = append(found, n)
+		}
+	}
+	if len(found) == 0 {
+		return "", false
+	}
+	sort.Strings(found)
+	return names[found[0]], true
+}
+
+// checkNegations ensures no flag has been given in both its normal and negated form.
+func (c Commands) checkNegations(flags flagArgs) error {
+	for _, nf := range flags {
+		if !nf.negated {
+			continue
+		}
+		for _, f := range flags {
+			if !f.negated && c.isAssignment(c[f.key]) && c[f.key] == c[nf.key] {
+				return fmt.Errorf("conflicting flags %s and %s", f.arg.Name, nf.arg.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package commandgo
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/eurozulu/commandgo/arguments"
+	"github.com/eurozulu/commandgo/functions"
+)
+
+// parseOptions creates the values of any options parameters of the function mapped to the given key.
+// The fields of each options value are set from their flags, which are removed from the given arguments.
+// returns the options values, keyed by their type, to be injected when calling the function.
+func (c Commands) parseOptions(ctx context.Context, key string, args arguments.Arguments) (map[reflect.Type]interface{}, error) {
+	cmd := c[key]
+	if !functions.IsFunc(cmd) {
+		return nil, nil
+	}
+	opts := map[reflect.Type]interface{}{}
+	for _, t := range c.caller(ctx, key).Signature(cmd).InjectedTypes {
+		if !functions.IsOptionsType(t) {
+			continue
+		}
+		v, p := functions.NewOptions(t)
+		oc := optionsCommands(t, p)
+		flags, err := oc.matchFlags(args)
+		if err == nil {
+			_, err = oc.invokeFlags(ctx, flags)
+		}
+		if err != nil {
+			return nil, err
+		}
+		opts[t] = v.Interface()
+	}
+	return opts, nil
+}
+
+// optionsCommands maps the flags of the given options type to the fields of the given struct pointer.
+func optionsCommands(t reflect.Type, p reflect.Value) Commands {
+	oc := Commands{}
+	for _, opt := range functions.Options(t) {
+		fp := p.Elem().FieldByIndex(opt.Index).Addr().Interface()
+		oc[opt.Name] = fp
+		if opt.Short != "" {
+			oc[opt.Short] = fp
+		}
+	}
+	return oc
+}
@@ -0,0 +1,147 @@
+package commandgo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eurozulu/commandgo/help"
+)
+
+const (
+	// ReferenceCommand is the hidden command, answered by Run, writing the reference pages of the commands into a directory.
+	// It is followed by the format of the pages, man or markdown, and the directory, the current directory by default.
+	// e.g. `restline __reference man ./man`
+	ReferenceCommand = "__reference"
+
+	// ReferenceMan is the format of roff man pages, written with the .1 extension.
+	ReferenceMan = "man"
+	// ReferenceMarkdown is the format of Markdown pages, written with the .md extension.
+	ReferenceMarkdown = "markdown"
+)
+
+// runReference answers the hidden reference command.
+// returns false if the arguments are not the reference command
+func (c Commands) runReference(args []string) ([]interface{}, bool, error) {
+	if len(args) == 0 || args[0] != ReferenceCommand {
+		return nil, false, nil
+	}
+	format, dir := ReferenceMan, "."
+	if len(args) > 1 {
+		format = args[1]
+	}
+	if len(args) > 2 {
+		dir = args[2]
+	}
+	files, err := c.WriteReferences(dir, format, filepath.Base(os.Args[0]))
+	if err != nil {
+		return nil, true, err
+	}
+	var lines []interface{}
+	for _, f := range files {
+		lines = append(lines, f)
+	}
+	return lines, true, nil
+}
+
+// References gets the reference of this map, as the command of the given program name, followed by those of its commands and sub maps.
+// Each command key, other than the default "" key, has its own reference, as does each sub map.
+// Commands and flags are described by their items in the help library, their comments and what they are mapped to.
+func (c Commands) References(prog string) []*help.Reference {
+	return c.references([]string{prog}, nil, "")
+}
+
+// WriteReferences writes the pages of the References, in the given format, into the given directory.
+// Each map and command is written to its own page, named by the keys leading to it. e.g. restline-get.1
+// returns the paths of the files written.
+func (c Commands) WriteReferences(dir, format, prog string) ([]string, error) {
+	var ext string
+	var page func(r *help.Reference) string
+	switch format {
+	case ReferenceMan:
+		ext, page = "1", (*help.Reference).ManPage
+	case ReferenceMarkdown:
+		ext, page = "md", (*help.Reference).Markdown
+	default:
+		return nil, fmt.Errorf("unknown reference format %s, expected %s or %s", format, ReferenceMan, ReferenceMarkdown)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, r := range c.References(prog) {
+		fn := filepath.Join(dir, r.FileName(ext))
+		if err := ioutil.WriteFile(fn, []byte(page(r)), 0644); err != nil {
+			return files, err
+		}
+		files = append(files, fn)
+	}
+	return files, nil
+}
+
+// references gets the reference of this map, with the given command, followed by those of its commands and sub maps.
+// path is the keys leading to this map, parent the command of the parent map, if it has one.
+// envPrefix is the environment prefix of the parent map.
+func (c Commands) references(command []string, path []string, envPrefix string) []*help.Reference {
+	envPrefix = c.envPrefix(envPrefix)
+	subject := subjectName(path)
+	r := &help.Reference{
+		Name:        strings.Join(command, " "),
+		Synopsis:    c.synopsis(),
+		Description: c.referenceDescription(subject),
+	}
+	if len(command) > 1 {
+		r.SeeAlso = append(r.SeeAlso, strings.Join(command[:len(command)-1], " "))
+	}
+	var subs []*help.Reference
+	for _, key := range c.groupedKeys() {
+		if key == "" {
+			continue
+		}
+		cmd := c[key]
+		hi := c.subjectItem(subject, key, envPrefix)
+		if hi.IsFlag() {
+			r.Options = append(r.Options, hi)
+		} else {
+			r.Commands = append(r.Commands, hi)
+		}
+		r.Options = append(r.Options, c.optionsItems(key)...)
+		sub := append(append([]string{}, command...), key)
+		switch {
+		case c.isSubmap(cmd):
+			sr := cmd.(Commands).references(sub, append(append([]string{}, path...), key), envPrefix)
+			r.SeeAlso = append(r.SeeAlso, sr[0].Name)
+			subs = append(subs, sr...)
+		case !hi.IsFlag():
+			cr := c.commandReference(sub, hi)
+			r.SeeAlso = append(r.SeeAlso, cr.Name)
+			subs = append(subs, cr)
+		}
+	}
+	return append([]*help.Reference{r}, subs...)
+}
+
+// commandReference gets the reference of the command, with the given item, mapped in this map.
+// command is the full command, ending with the key of the command.
+func (c Commands) commandReference(command []string, hi *help.HelpItem) *help.Reference {
+	return &help.Reference{
+		Name:        strings.Join(command, " "),
+		Synopsis:    []string{strings.TrimSpace(fmt.Sprintf("[flags] %s", hi.Usage))},
+		Description: hi.Comment,
+		Options:     c.optionsItems(hi.Key),
+		SeeAlso:     []string{strings.Join(command[:len(command)-1], " ")},
+	}
+}
+
+// referenceDescription gets the description of this map, from the comment of its subject in the help library, or of its "" key.
+func (c Commands) referenceDescription(subject string) string {
+	if hs := help.FindSubject(subject); hs != nil && hs.Comment != "" {
+		return hs.Comment
+	}
+	if _, ok := c[""]; ok {
+		return c.description("")
+	}
+	return ""
+}
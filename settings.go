@@ -0,0 +1,174 @@
+package commandgo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/eurozulu/commandgo/completion"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// settings holds the optional behaviour attached to a Commands map.
+// Settings are held within the map they are attached to, under the SettingsKey, so are owned, and released, along with the map.
+type settings struct {
+	// owner identifies the map the settings were attached to, so a map with settings copied from another has its own when changed.
+	owner uintptr
+
+	// clusterFlags, when true, expands clustered single letter flags. e.g. -abc into -a -b -c
+	clusterFlags bool
+
+	// counters are the keys declared as counters
+	counters map[string]bool
+
+	// env maps keys to the environment variable they are bound to
+	env map[string]string
+
+	// envPrefix, when set, binds all the long flags in the map and its sub maps to environment variables with this prefix.
+	envPrefix string
+
+	// sources records where the assignments of the map were last set from, keyed by the assignment.
+	sources map[interface{}]*ValueSource
+
+	// constraints are the rules the flags of the map must follow
+	constraints []*constraint
+
+	// validators check the values of assignments, keyed by the assignment.
+	validators map[interface{}][]values.Validator
+
+	// paramValidators check the parameters of functions, keyed by the key and parameter position.
+	paramValidators map[string]map[int][]values.Validator
+
+	// inject are the values injected into function parameters of the same type, keyed by their type.
+	inject map[reflect.Type]interface{}
+
+	// comments are the help text of keys, given with the key, rather than from the help library.
+	comments map[string]string
+
+	// aliases maps the aliases of keys to their key
+	aliases map[string]string
+
+	// flagCompletions provide the completion candidates of flag values, keyed by the flag key.
+	flagCompletions map[string]completion.Provider
+
+	// paramCompletions provide the completion candidates of function parameters, keyed by the key and parameter position.
+	paramCompletions map[string]map[int]completion.Provider
+}
+
+// SettingsKey is the reserved key the settings of a map are held under.
+// Command line arguments can not contain a NUL, so the key is never matched as a command or flag.
+// Code ranging over a map must skip this key. e.g.
+//
+//	for k, v := range cmds {
+//		if k == commandgo.SettingsKey {
+//			continue
+//		}
+//	}
+const SettingsKey = "\x00settings"
+
+// settings gets the settings attached to this map.
+// If no settings have been attached, the default settings are returned.
+func (c Commands) settings() *settings {
+	if s, ok := c[SettingsKey].(*settings); ok {
+		return s
+	}
+	return &settings{}
+}
+
+// ensureSettings gets the settings of this map, to be changed, attaching new, default settings if none exist.
+// Settings copied, along with the entries, from another map are cloned, so changing them does not change the other map.
+func (c Commands) ensureSettings() *settings {
+	owner := reflect.ValueOf(c).Pointer()
+	s, ok := c[SettingsKey].(*settings)
+	switch {
+	case !ok:
+		s = &settings{owner: owner}
+		c[SettingsKey] = s
+	case s.owner != owner:
+		s = s.clone(owner)
+		c[SettingsKey] = s
+	}
+	return s
+}
+
+// clone copies the settings, with their own copies of the maps and lists they contain, for the given owner.
+func (s *settings) clone(owner uintptr) *settings {
+	cs := *s
+	cs.owner = owner
+	cs.counters = map[string]bool{}
+	for k, v := range s.counters {
+		cs.counters[k] = v
+	}
+	cs.env = mergeKeys(nil, s.env)
+	cs.comments = mergeKeys(nil, s.comments)
+	cs.aliases = mergeKeys(nil, s.aliases)
+	cs.sources = map[interface{}]*ValueSource{}
+	for k, v := range s.sources {
+		cs.sources[k] = v
+	}
+	cs.constraints = append([]*constraint(nil), s.constraints...)
+	cs.validators = map[interface{}][]values.Validator{}
+	for k, v := range s.validators {
+		cs.validators[k] = append([]values.Validator(nil), v...)
+	}
+	cs.paramValidators = map[string]map[int][]values.Validator{}
+	for k, pvs := range s.paramValidators {
+		cs.paramValidators[k] = map[int][]values.Validator{}
+		for pos, v := range pvs {
+			cs.paramValidators[k][pos] = append([]values.Validator(nil), v...)
+		}
+	}
+	cs.inject = map[reflect.Type]interface{}{}
+	for k, v := range s.inject {
+		cs.inject[k] = v
+	}
+	cs.flagCompletions = map[string]completion.Provider{}
+	for k, v := range s.flagCompletions {
+		cs.flagCompletions[k] = v
+	}
+	cs.paramCompletions = map[string]map[int]completion.Provider{}
+	for k, ps := range s.paramCompletions {
+		cs.paramCompletions[k] = map[int]completion.Provider{}
+		for pos, p := range ps {
+			cs.paramCompletions[k][pos] = p
+		}
+	}
+	return &cs
+}
+
+// ClusterFlags enables or disables the expansion of clustered single letter flags.
+// When enabled, an argument such as -vI is expanded into -v -I, when both are single letter flags in this map or any of its sub maps.
+// The last flag in a cluster may have its value attached, e.g. -p0640 is read as -p=0640, when -p is mapped to a non bool assignment.
+// An argument which matches a key in its entirety, as well as expanding into a cluster is reported as ambiguous.
+// Clusters are expanded once, from the map Run is called on, so should be enabled on the 'top' map.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) ClusterFlags(enable bool) Commands {
+	c.ensureSettings().clusterFlags = enable
+	return c
+}
+
+// Counter declares the given keys as counters.
+// Each key must be mapped to an int or uint assignment.
+// A counter flag takes no following parameter, instead each occurrence of the flag increments its assignment by one.
+// e.g. -v -v -v or -vvv sets the assignment to 3 more than its current value.
+// A counter can still be set to a specific value using an inline value, e.g. -v=2
+// panics if any of the keys are not mapped to an int or uint assignment
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Counter(keys ...string) Commands {
+	s := c.ensureSettings()
+	if s.counters == nil {
+		s.counters = map[string]bool{}
+	}
+	for _, k := range keys {
+		cmd, ok := c[k]
+		if !ok || !c.isAssignment(cmd) || !values.IsInteger(cmd) {
+			panic(fmt.Sprintf("counter %s is not mapped to an int assignment", k))
+		}
+		s.counters[k] = true
+	}
+	return c
+}
+
+// isCounter checks if the given key has been declared as a counter.
+func (c Commands) isCounter(key string) bool {
+	return c.settings().counters[key]
+}
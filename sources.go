@@ -0,0 +1,171 @@
+package commandgo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eurozulu/commandgo/help"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// ShowConfigFlag is the flag, added to the top map, to show the values of all the assignments and where they came from.
+const ShowConfigFlag = "--show-config"
+
+// ShowConfigRequested indicates the values of the assignments should be shown, rather than the command invoked.
+var ShowConfigRequested bool
+
+// Source is the layer which set the value of an assignment.
+type Source int
+
+const (
+	// SourceDefault is the value the assignment had before the command line was run.
+	SourceDefault Source = iota
+	// SourceConfig is a value read from a config file
+	SourceConfig
+	// SourceEnv is a value read from an environment variable
+	SourceEnv
+	// SourceFlag is a value given in the command line
+	SourceFlag
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceConfig:
+		return "config"
+	case SourceEnv:
+		return "env"
+	case SourceFlag:
+		return "flag"
+	default:
+		return "default"
+	}
+}
+
+// ValueSource records where the value of an assignment was last set from.
+// Key is the key of the assignment, preceded by the keys of any sub maps containing it.
+// Raw is the string the value was parsed from.
+// Origin names the source, being the file and line of a config value, the environment variable name or the flag as given.
+type ValueSource struct {
+	Key    string
+	Source Source
+	Raw    string
+	Origin string
+}
+
+func (vs ValueSource) String() string {
+	if vs.Origin == "" {
+		return fmt.Sprintf("%s = %s\t(%s)", vs.Key, vs.Raw, vs.Source)
+	}
+	return fmt.Sprintf("%s = %s\t(%s %s)", vs.Key, vs.Raw, vs.Source, vs.Origin)
+}
+
+// ValueSources gets the sources of all the assignments in this map and its sub maps, from the last time it was Run.
+// Assignments mapped to more than one key have a single source, naming the key that last set it.
+func (c Commands) ValueSources() []*ValueSource {
+	return c.collectSources(nil)
+}
+
+// ValueSource gets the source of the assignment mapped to the given key, from the last time the map was Run.
+// Keys of sub maps may precede the key to name an assignment in that sub map. e.g. ValueSource("post", "--content-type")
+// returns false if the key is unknown or not an assignment.
+func (c Commands) ValueSource(keys ...string) (*ValueSource, bool) {
+	cmds := c
+	for i, key := range keys {
+		k, ok := cmds.findKey(key)
+		if !ok {
+			return nil, false
+		}
+		if i < len(keys)-1 {
+			if !cmds.isSubmap(cmds[k]) {
+				return nil, false
+			}
+			cmds = cmds[k].(Commands)
+			continue
+		}
+		if !cmds.isAssignment(cmds[k]) {
+			return nil, false
+		}
+		vs, ok := cmds.settings().sources[cmds[k]]
+		if !ok {
+			return nil, false
+		}
+		cp := *vs
+		cp.Key = strings.Join(append(keys[:i:i], vs.Key), " ")
+		return &cp, true
+	}
+	return nil, false
+}
+
+// collectSources collects the sources of this map and its sub maps, in key order.
+func (c Commands) collectSources(path []string) []*ValueSource {
+	var sources []*ValueSource
+	seen := map[interface{}]bool{}
+	for _, k := range c.sortedKeys() {
+		cmd := c[k]
+		if c.isSubmap(cmd) {
+			sources = append(sources, cmd.(Commands).collectSources(append(path[:len(path):len(path)], k))...)
+			continue
+		}
+		if !c.isAssignment(cmd) || seen[cmd] {
+			continue
+		}
+		vs, ok := c.settings().sources[cmd]
+		if !ok {
+			continue
+		}
+		seen[cmd] = true
+		cp := *vs
+		cp.Key = strings.Join(append(path[:len(path):len(path)], vs.Key), " ")
+		sources = append(sources, &cp)
+	}
+	return sources
+}
+
+// recordSource records the given source as the last to set the assignment of the given key.
+// The help and show config flags are not recorded.
+func (c Commands) recordSource(key string, source Source, raw, origin string) {
+	if isBuiltinFlag(c[key]) {
+		return
+	}
+	s := c.ensureSettings()
+	if s.sources == nil {
+		s.sources = map[interface{}]*ValueSource{}
+	}
+	s.sources[c[key]] = &ValueSource{Key: key, Source: source, Raw: raw, Origin: origin}
+}
+
+// recordDefaults clears all the sources of this map and its sub maps, recording the current values of the assignments as their defaults.
+// The values are left unchanged, so any set by a previous Run are the defaults of this one.
+// The help and show config flags are not recorded.
+func (c Commands) recordDefaults() {
+	s := c.ensureSettings()
+	s.sources = map[interface{}]*ValueSource{}
+	for _, k := range c.sortedKeys() {
+		cmd := c[k]
+		if c.isSubmap(cmd) {
+			cmd.(Commands).recordDefaults()
+			continue
+		}
+		if !c.isAssignment(cmd) || isBuiltinFlag(cmd) {
+			continue
+		}
+		if _, ok := s.sources[cmd]; ok {
+			continue
+		}
+		s.sources[cmd] = &ValueSource{Key: k, Source: SourceDefault, Raw: values.FormatValue(cmd)}
+	}
+}
+
+// isBuiltinFlag checks if the given command is the assignment of the help or show config flags, added by the framework.
+func isBuiltinFlag(cmd interface{}) bool {
+	return cmd == &help.HelpRequested || cmd == &ShowConfigRequested
+}
+
+// showConfig gets the sources of all the assignments as lines of text
+func (c Commands) showConfig() []interface{} {
+	var lines []interface{}
+	for _, vs := range c.ValueSources() {
+		lines = append(lines, vs.String())
+	}
+	return lines
+}
@@ -0,0 +1,203 @@
+package commandgo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/eurozulu/commandgo/completion"
+	"github.com/eurozulu/commandgo/functions"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// Struct tags read by FromStruct
+const (
+	// TagName names the flag of a field, in the form `cmd:"name,short,help"`, as with options types. A name of "-" excludes the field.
+	TagName = functions.OptionsTag
+	// TagAliases lists other names of the field, comma delimited.
+	TagAliases = "aliases"
+	// TagEnv names the environment variable bound to the field.
+	TagEnv = "env"
+	// TagDefault is the default value of the field.
+	TagDefault = "default"
+	// TagHelp is the help text of the field.
+	TagHelp = "help"
+)
+
+// FromStruct creates a new Commands map of the fields and methods of the given struct pointer.
+// Each exported field is mapped as an assignment to a flag, named by its `cmd:"name,short,help"` tag, or the field name in kebab case.
+// e.g. LocalFilePermissions is --local-file-permissions. The short flag is an alias of the flag.
+// Fields may also be tagged with:
+// `aliases:"--perm,-p"` aliases of the field's flag. See Alias
+// `env:"RESTLINE_PERMISSIONS"` the environment variable bound to the field
+// `default:"0640"` the value the field is set to
+// `help:"permissions of local files"` the help text of the field, in place of any given in the `cmd` tag
+// Each exported method is mapped as a command, named by the method name in kebab case. e.g. GetLocal is get-local.
+// The returned map is an ordinary Commands map, which may be extended or merged with others. See Merge.
+// panics if the given interface is not a pointer to a struct or a tag can not be applied.
+func FromStruct(i interface{}) Commands {
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%T is not a pointer to a struct", i))
+	}
+	c := Commands{}
+	st := v.Elem().Type()
+	for index := 0; index < st.NumField(); index++ {
+		f := st.Field(index)
+		opt, ok := functions.FieldOption(f)
+		if !ok {
+			continue
+		}
+		key := opt.Name
+		fp := v.Elem().Field(index).Addr().Interface()
+		c.addStructKey(key, fp)
+		if opt.Short != "" {
+			c.Alias(key, opt.Short)
+		}
+		for _, al := range strings.Split(f.Tag.Get(TagAliases), ",") {
+			if al = strings.TrimSpace(al); al != "" {
+				c.Alias(key, al)
+			}
+		}
+		if env := f.Tag.Get(TagEnv); env != "" {
+			c.Env(key, env)
+		}
+		if def, ok := f.Tag.Lookup(TagDefault); ok {
+			if err := values.SetValue(fp, def); err != nil {
+				panic(fmt.Sprintf("default of %s, %v", f.Name, err))
+			}
+		}
+		if h := f.Tag.Get(TagHelp); h != "" {
+			c.setComment(key, h)
+		} else if opt.Help != "" {
+			c.setComment(key, opt.Help)
+		}
+	}
+	for index := 0; index < v.NumMethod(); index++ {
+		c.addStructKey(functions.KebabCase(v.Type().Method(index).Name), v.Method(index).Interface())
+	}
+	return c
+}
+
+// addStructKey maps the given key, panicking if the key is already mapped.
+func (c Commands) addStructKey(key string, cmd interface{}) {
+	if _, ok := c[key]; ok {
+		panic(fmt.Sprintf("key %s is mapped more than once", key))
+	}
+	c[key] = cmd
+}
+
+// setComment sets the help text of the given key
+func (c Commands) setComment(key, text string) {
+	s := c.ensureSettings()
+	if s.comments == nil {
+		s.comments = map[string]string{}
+	}
+	s.comments[key] = text
+}
+
+// Merge adds the keys of the given maps, along with their settings, such as environment variables, validators and constraints, to this map.
+// A key, or alias, may be in more than one map only when mapped to the same assignment.
+// panics if a key or alias is in more than one map, mapped to different commands.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Merge(maps ...Commands) Commands {
+	for _, m := range maps {
+		for _, k := range m.sortedKeys() {
+			if cmd := m[k]; c.canMerge(k, cmd) {
+				c[k] = cmd
+			}
+		}
+		for al, k := range m.settings().aliases {
+			c.canMerge(al, m[k])
+		}
+		c.mergeSettings(m.settings())
+	}
+	return c
+}
+
+// canMerge checks if the given key, or alias, of a merged map is not already a key or alias of this map.
+// panics if it is already mapped to a command other than the given one, unless both are the same assignment.
+func (c Commands) canMerge(k string, cmd interface{}) bool {
+	ek := k
+	if ak, ok := c.settings().aliases[k]; ok {
+		ek = ak
+	}
+	ec, ok := c[ek]
+	if !ok {
+		return true
+	}
+	if !c.isAssignment(ec) || !c.isAssignment(cmd) || ec != cmd {
+		panic(fmt.Sprintf("key %s can not be merged, it is already mapped", k))
+	}
+	return false
+}
+
+// mergeSettings adds the given settings to the settings of this map.
+func (c Commands) mergeSettings(ms *settings) {
+	s := c.ensureSettings()
+	s.clusterFlags = s.clusterFlags || ms.clusterFlags
+	if s.envPrefix == "" {
+		s.envPrefix = ms.envPrefix
+	}
+	for k := range ms.counters {
+		if s.counters == nil {
+			s.counters = map[string]bool{}
+		}
+		s.counters[k] = true
+	}
+	s.env = mergeKeys(s.env, ms.env)
+	s.comments = mergeKeys(s.comments, ms.comments)
+	s.constraints = append(s.constraints, ms.constraints...)
+	s.aliases = mergeKeys(s.aliases, ms.aliases)
+	for cmd, vs := range ms.validators {
+		if s.validators == nil {
+			s.validators = map[interface{}][]values.Validator{}
+		}
+		s.validators[cmd] = append(s.validators[cmd], vs...)
+	}
+	for k, pvs := range ms.paramValidators {
+		if s.paramValidators == nil {
+			s.paramValidators = map[string]map[int][]values.Validator{}
+		}
+		if _, ok := s.paramValidators[k]; !ok {
+			s.paramValidators[k] = pvs
+		}
+	}
+	for k, p := range ms.flagCompletions {
+		if s.flagCompletions == nil {
+			s.flagCompletions = map[string]completion.Provider{}
+		}
+		if _, ok := s.flagCompletions[k]; !ok {
+			s.flagCompletions[k] = p
+		}
+	}
+	for k, ps := range ms.paramCompletions {
+		if s.paramCompletions == nil {
+			s.paramCompletions = map[string]map[int]completion.Provider{}
+		}
+		if _, ok := s.paramCompletions[k]; !ok {
+			s.paramCompletions[k] = ps
+		}
+	}
+	for t, v := range ms.inject {
+		if s.inject == nil {
+			s.inject = map[reflect.Type]interface{}{}
+		}
+		if _, ok := s.inject[t]; !ok {
+			s.inject[t] = v
+		}
+	}
+}
+
+// mergeKeys adds the entries of the given map, which are not already in the destination map.
+func mergeKeys(dst, src map[string]string) map[string]string {
+	for k, v := range src {
+		if dst == nil {
+			dst = map[string]string{}
+		}
+		if _, ok := dst[k]; !ok {
+			dst[k] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,60 @@
+package commandgo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eurozulu/commandgo/functions"
+	"github.com/eurozulu/commandgo/values"
+)
+
+// Validate attaches validators to the assignment mapped to the given key.
+// Values for the assignment are checked after they are parsed and before they are assigned,
+// whether they come from a flag, config file or environment variable.
+// Validators are attached to the assignment itself, so apply to all the keys mapped to it.
+// panics if the key is not mapped to an assignment.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) Validate(key string, validators ...values.Validator) Commands {
+	cmd, ok := c[key]
+	if !ok || !c.isAssignment(cmd) {
+		panic(fmt.Sprintf("validated key %s is not mapped to an assignment", key))
+	}
+	s := c.ensureSettings()
+	if s.validators == nil {
+		s.validators = map[interface{}][]values.Validator{}
+	}
+	s.validators[cmd] = append(s.validators[cmd], validators...)
+	return c
+}
+
+// ValidateParam attaches validators to a parameter of the function mapped to the given key.
+// pos is the position of the parameter, starting at zero. Validators for a variadic parameter check each of its arguments.
+// Parameters are checked after they are parsed and before the function is called.
+// panics if the key is not mapped to a function or the position is beyond its parameters.
+// returns the same Commands, to allow it to be chained on a new map.
+func (c Commands) ValidateParam(key string, pos int, validators ...values.Validator) Commands {
+	cmd, ok := c[key]
+	if !ok || !functions.IsFunc(cmd) {
+		panic(fmt.Sprintf("validated key %s is not mapped to a function", key))
+	}
+	if pos < 0 || pos >= len(c.caller(context.Background(), key).Signature(cmd).ParamTypes) {
+		panic(fmt.Sprintf("%s has no parameter at position %d", key, pos))
+	}
+	s := c.ensureSettings()
+	if s.paramValidators == nil {
+		s.paramValidators = map[string]map[int][]values.Validator{}
+	}
+	if s.paramValidators[key] == nil {
+		s.paramValidators[key] = map[int][]values.Validator{}
+	}
+	s.paramValidators[key][pos] = append(s.paramValidators[key][pos], validators...)
+	return c
+}
+
+// validators gets the validators attached to the given assignment
+func (c Commands) validators(cmd interface{}) []values.Validator {
+	if !c.isAssignment(cmd) {
+		return nil
+	}
+	return c.settings().validators[cmd]
+}
@@ -0,0 +1,104 @@
+package values
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// enum holds the named values of an enumerated type
+type enum struct {
+	names  []string
+	values map[string]reflect.Value
+}
+
+var enums = map[reflect.Type]*enum{}
+
+// NewEnum registers the given type as an enumeration of the given named values.
+// Arguments are parsed by name, ignoring case, and any prefix which matches a single name is accepted.
+// e.g. with names DEV, TEST and PROD, "dev", "Prod" and "t" are all valid.
+// Values are formatted by their name and the names are listed as the choices of the type.
+// Each value must be convertible to the given type. A nil names map removes the enumeration.
+// panics if a value can not be converted to the type.
+func NewEnum(t reflect.Type, names map[string]interface{}) {
+	if names == nil {
+		delete(enums, t)
+		NewCustomType(t, nil)
+		return
+	}
+	e := &enum{values: map[string]reflect.Value{}}
+	for n, v := range names {
+		rv := reflect.ValueOf(v)
+		if !rv.IsValid() || !rv.Type().ConvertibleTo(t) {
+			panic(fmt.Sprintf("enum value %s, %v is not a %s", n, v, t.String()))
+		}
+		e.names = append(e.names, n)
+		e.values[n] = rv.Convert(t)
+	}
+	sort.Strings(e.names)
+	enums[t] = e
+	NewCustomType(t, e.parse)
+}
+
+// IsEnum checks if the given type, or the type it points to, has been registered as an enumeration.
+func IsEnum(t reflect.Type) bool {
+	return findEnum(t) != nil
+}
+
+// EnumChoices gets the names of the given enumerated type, or the type it points to, in name order.
+// returns nil if the type is not an enumeration.
+func EnumChoices(t reflect.Type) []string {
+	e := findEnum(t)
+	if e == nil {
+		return nil
+	}
+	return append([]string{}, e.names...)
+}
+
+// EnumName gets the name of the given enumerated value, or the value it points to.
+// returns false if the value is not an enumeration or has no name.
+func EnumName(v interface{}) (string, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return "", false
+	}
+	e := enums[rv.Type()]
+	if e == nil {
+		return "", false
+	}
+	for _, n := range e.names {
+		if e.values[n].Interface() == rv.Interface() {
+			return n, true
+		}
+	}
+	return "", false
+}
+
+func findEnum(t reflect.Type) *enum {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return enums[t]
+}
+
+// parse finds the value named by the given string, first by its full name, then by a unique prefix.
+func (e enum) parse(s string, t reflect.Type) (interface{}, error) {
+	var found []string
+	for _, n := range e.names {
+		if strings.EqualFold(n, s) {
+			return e.values[n].Interface(), nil
+		}
+		if len(s) > 0 && len(s) < len(n) && strings.EqualFold(n[:len(s)], s) {
+			found = append(found, n)
+		}
+	}
+	switch len(found) {
+	case 1:
+		return e.values[found[0]].Interface(), nil
+	case 0:
+		return nil, fmt.Errorf("%q is not a valid %s, must be one of %s", s, t.Name(), strings.Join(e.names, ", "))
+	default:
+		return nil, fmt.Errorf("%q is ambiguous, could be %s", s, strings.Join(found, ", "))
+	}
+}
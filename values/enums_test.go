@@ -0,0 +1,65 @@
+package values_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/eurozulu/commandgo/values"
+)
+
+type testEnvironment int
+
+const (
+	testDev testEnvironment = iota
+	testDevel
+	testProd
+)
+
+func TestNewEnum(t *testing.T) {
+	et := reflect.TypeOf(testEnvironment(0))
+	values.NewEnum(et, map[string]interface{}{
+		"DEV":   testDev,
+		"DEVEL": testDevel,
+		"PROD":  2,
+	})
+	defer values.NewEnum(et, nil)
+
+	tests := map[string]testEnvironment{
+		"dev":   testDev,
+		"Devel": testDevel,
+		"PROD":  testProd,
+		"p":     testProd,
+		"deve":  testDevel,
+	}
+	for s, expect := range tests {
+		v, err := values.ValueFromString(s, et)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q, %v", s, err)
+		}
+		if v.(testEnvironment) != expect {
+			t.Fatalf("unexpected value parsing %q, expected %d, found %d", s, expect, v)
+		}
+	}
+	if _, err := values.ValueFromString("d", et); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Fatalf("expected ambiguous error, found %v", err)
+	}
+	if _, err := values.ValueFromString("test", et); err == nil {
+		t.Fatalf("expected error parsing unknown name, found none")
+	}
+
+	var env testEnvironment
+	if err := values.SetValue(&env, "prod"); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if s := values.FormatValue(&env); s != "PROD" {
+		t.Fatalf("unexpected formatted value, expected PROD, found %s", s)
+	}
+	choices := values.EnumChoices(reflect.TypeOf(&env))
+	if strings.Join(choices, ",") != "DEV,DEVEL,PROD" {
+		t.Fatalf("unexpected choices, %v", choices)
+	}
+	if values.IsEnum(reflect.TypeOf("")) {
+		t.Fatalf("unexpected enum for string type")
+	}
+}
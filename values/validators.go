@@ -0,0 +1,118 @@
+package values
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Validator checks a value, parsed from an argument, is valid before it is used.
+// The value is of the type being assigned, the element of an assignment pointer or the type of a function parameter.
+// returns an error describing why the value is invalid, or nil if it is valid.
+type Validator func(v interface{}) error
+
+// Validate checks the given value with each of the given validators, returning the first error found.
+func Validate(v interface{}, validators ...Validator) error {
+	for _, vf := range validators {
+		if err := vf(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range validates numeric values are within the given min and max, inclusive.
+// Slices have each of their items validated.
+func Range(min, max float64) Validator {
+	return eachItem(func(v reflect.Value) error {
+		var f float64
+		switch v.Kind() {
+		case reflect.Int64, reflect.Int32, reflect.Int16, reflect.Int8, reflect.Int:
+			f = float64(v.Int())
+		case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
+			f = float64(v.Uint())
+		case reflect.Float64, reflect.Float32:
+			f = v.Float()
+		default:
+			return fmt.Errorf("%s is not a number", v.Type().String())
+		}
+		if f < min || f > max {
+			return fmt.Errorf("%v is out of range, must be between %v and %v", v.Interface(), min, max)
+		}
+		return nil
+	})
+}
+
+// Match validates the string form of values match the given regular expression.
+// Slices have each of their items validated.
+// panics if the pattern is not a valid regular expression.
+func Match(pattern string) Validator {
+	re := regexp.MustCompile(pattern)
+	return eachItem(func(v reflect.Value) error {
+		s := itemString(v)
+		if !re.MatchString(s) {
+			return fmt.Errorf("%q does not match %s", s, pattern)
+		}
+		return nil
+	})
+}
+
+// OneOf validates the string form of values is one of the given choices.
+// Slices have each of their items validated.
+func OneOf(choices ...string) Validator {
+	return eachItem(func(v reflect.Value) error {
+		s := itemString(v)
+		for _, c := range choices {
+			if s == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not valid, must be one of %s", s, strings.Join(choices, ", "))
+	})
+}
+
+// FileExists validates string values name an existing file or directory.
+// Slices have each of their items validated.
+func FileExists() Validator {
+	return eachItem(func(v reflect.Value) error {
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("%s is not a file name", v.Type().String())
+		}
+		if _, err := os.Stat(v.String()); err != nil {
+			if os.IsNotExist(err) {
+				return fmt.Errorf("file %s does not exist", v.String())
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// eachItem wraps the given check into a Validator, calling it with the value, or each item of a slice value.
+// Pointers are followed to the value they point to.
+func eachItem(check func(v reflect.Value) error) Validator {
+	return func(i interface{}) error {
+		v := reflect.Indirect(reflect.ValueOf(i))
+		if v.Kind() != reflect.Slice {
+			return check(v)
+		}
+		for index := 0; index < v.Len(); index++ {
+			if err := check(reflect.Indirect(v.Index(index))); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// itemString gets the string form of the given value, using the String method of its pointer when it has one.
+func itemString(v reflect.Value) string {
+	if v.CanAddr() {
+		if sv, ok := v.Addr().Interface().(fmt.Stringer); ok {
+			return sv.String()
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,65 @@
+package values_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/eurozulu/commandgo/values"
+)
+
+func TestValidators(t *testing.T) {
+	var perm os.FileMode
+	if err := values.SetValue(&perm, "420", values.Range(0, 0777)); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := values.SetValue(&perm, "99999", values.Range(0, 0777)); err == nil {
+		t.Fatalf("expected out of range error, found none")
+	}
+	if perm != 0644 {
+		t.Fatalf("unexpected value after failed validation, expected %o, found %o", 0644, perm)
+	}
+
+	var ss []string
+	if err := values.AppendValue(&ss, "get,post", values.OneOf("get", "post", "put")); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := values.AppendValue(&ss, "get,fetch", values.OneOf("get", "post", "put")); err == nil {
+		t.Fatalf("expected one of error, found none")
+	}
+	if len(ss) != 2 {
+		t.Fatalf("unexpected values after failed validation, %v", ss)
+	}
+
+	var s string
+	if err := values.SetValue(&s, "abc123", values.Match(`^[a-z]+\d+$`)); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := values.SetValue(&s, "123abc", values.Match(`^[a-z]+\d+$`)); err == nil {
+		t.Fatalf("expected match error, found none")
+	}
+
+	f, err := ioutil.TempFile("", "validator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if err := values.SetValue(&s, f.Name(), values.FileExists()); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := values.SetValue(&s, f.Name()+".missing", values.FileExists()); err == nil {
+		t.Fatalf("expected file exists error, found none")
+	}
+
+	var count int
+	if err := values.Increment(&count, values.Range(0, 1)); err != nil {
+		t.Fatalf("unexpected error, %v", err)
+	}
+	if err := values.Increment(&count, values.Range(0, 1)); err == nil {
+		t.Fatalf("expected out of range error incrementing, found none")
+	}
+	if count != 1 {
+		t.Fatalf("unexpected count after failed validation, expected 1, found %d", count)
+	}
+}
@@ -100,6 +100,31 @@ func IsKind(i interface{}, k reflect.Kind) bool {
 	return t.Kind() == k
 }
 
+// IsInteger checks if the given interface, or the element it points to, is an int or uint kind
+func IsInteger(i interface{}) bool {
+	for _, k := range []reflect.Kind{
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64} {
+		if IsKind(i, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNumeric checks if the given type, or the element of a pointer, slice or array type, is an int, uint or float kind
+func IsNumeric(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		return IsNumeric(t.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
 func GetValue(r interface{}) interface{} {
 	t := reflect.TypeOf(r)
 	if t.Kind() == reflect.Ptr {
@@ -108,9 +133,41 @@ func GetValue(r interface{}) interface{} {
 	return reflect.ValueOf(r).Interface()
 }
 
+// FormatValue gets the string form of the value the given pointer points to.
+// Slices are formatted as a list delimited with the SliceDelimiter, nil values as an empty string.
+// Enumerated values are formatted by their name.
+func FormatValue(r interface{}) string {
+	v := reflect.ValueOf(r)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if n, ok := EnumName(v.Interface()); ok {
+		return n
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		if v.IsNil() {
+			return ""
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			items := make([]string, v.Len())
+			for i := range items {
+				items[i] = FormatValue(v.Index(i).Interface())
+			}
+			return strings.Join(items, SliceDelimiter)
+		}
+	}
+	return fmt.Sprint(v.Interface())
+}
+
 // Sets the given receiver with the given value.
 // Assigns the value or a pointer to it, depending on the reciever type
-func SetValue(r interface{}, val string) error {
+// Any validators given check the parsed value, as it is assigned to the receiver, before it is assigned.
+func SetValue(r interface{}, val string, validators ...Validator) error {
 	iVal, err := ValueFromString(val, reflect.TypeOf(r))
 	if err != nil {
 		return err
@@ -125,10 +182,88 @@ func SetValue(r interface{}, val string) error {
 	if v.Type().Kind() == reflect.Ptr {
 		v = v.Elem()
 	}
+	if err := Validate(v.Interface(), validators...); err != nil {
+		return err
+	}
 	recv.Set(v)
 	return nil
 }
 
+// AppendValue adds the given value to the receiver, when the receiver is a slice or map.
+// The value is parsed as the same type as the receiver, with slices appending the parsed items and
+// maps merging the parsed entries, replacing any existing keys.
+// All other types are set, as with SetValue
+// Any validators given check the parsed value before it is added.
+func AppendValue(r interface{}, val string, validators ...Validator) error {
+	recv := reflect.ValueOf(r)
+	if recv.Type().Kind() != reflect.Ptr {
+		return SetValue(r, val, validators...)
+	}
+	recv = recv.Elem()
+
+	switch recv.Kind() {
+	case reflect.Slice:
+		iVal, err := ValueFromString(val, recv.Type())
+		if err != nil {
+			return err
+		}
+		if err := Validate(iVal, validators...); err != nil {
+			return err
+		}
+		recv.Set(reflect.AppendSlice(recv, reflect.Indirect(reflect.ValueOf(iVal))))
+		return nil
+
+	case reflect.Map:
+		iVal, err := ValueFromString(val, recv.Type())
+		if err != nil {
+			return err
+		}
+		if err := Validate(iVal, validators...); err != nil {
+			return err
+		}
+		if recv.IsNil() {
+			recv.Set(reflect.MakeMap(recv.Type()))
+		}
+		iter := reflect.Indirect(reflect.ValueOf(iVal)).MapRange()
+		for iter.Next() {
+			recv.SetMapIndex(iter.Key(), iter.Value())
+		}
+		return nil
+
+	default:
+		return SetValue(r, val, validators...)
+	}
+}
+
+// Increment adds one to the given receiver.
+// The receiver must be a pointer to an int or uint kind.
+// Any validators given check the incremented value before it is assigned.
+func Increment(r interface{}, validators ...Validator) error {
+	recv := reflect.ValueOf(r)
+	if recv.Type().Kind() != reflect.Ptr || !IsInteger(r) {
+		return fmt.Errorf("%s can not be incremented", recv.Type().String())
+	}
+	recv = recv.Elem()
+	nv := reflect.New(recv.Type()).Elem()
+	switch recv.Kind() {
+	case reflect.Uint64, reflect.Uint32, reflect.Uint16, reflect.Uint8, reflect.Uint:
+		if recv.OverflowUint(recv.Uint() + 1) {
+			return fmt.Errorf("%s can not be incremented beyond %d", recv.Type().String(), recv.Uint())
+		}
+		nv.SetUint(recv.Uint() + 1)
+	default:
+		if recv.OverflowInt(recv.Int() + 1) {
+			return fmt.Errorf("%s can not be incremented beyond %d", recv.Type().String(), recv.Int())
+		}
+		nv.SetInt(recv.Int() + 1)
+	}
+	if err := Validate(nv.Interface(), validators...); err != nil {
+		return err
+	}
+	recv.Set(nv)
+	return nil
+}
+
 func structureFromString(s string, t reflect.Type) (interface{}, error) {
 	pStr := reflect.New(t)
 	if s == "" {
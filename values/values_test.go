@@ -33,6 +33,22 @@ func TestIsKind(t *testing.T) {
 	}
 }
 
+func TestIsNumeric(t *testing.T) {
+	var i int
+	var f []float64
+	var u *uint8
+	for _, v := range []interface{}{&i, &f, &u, testIntType(1)} {
+		if !values.IsNumeric(reflect.TypeOf(v)) {
+			t.Fatalf("expected %T to be numeric", v)
+		}
+	}
+	for _, v := range []interface{}{&testvarBool, &testvarString, testvarUrl, []string{}} {
+		if values.IsNumeric(reflect.TypeOf(v)) {
+			t.Fatalf("expected %T not to be numeric", v)
+		}
+	}
+}
+
 func TestValueFromString_int(t *testing.T) {
 	v, err := values.ValueFromString("555", reflect.TypeOf(0))
 	if err != nil {
@@ -344,3 +360,24 @@ func TestValueFromString_struct(t *testing.T) {
 		t.Fatalf("unexpected values found in returned test object")
 	}
 }
+
+func TestIncrement(t *testing.T) {
+	var i int
+	var u uint8 = 254
+	if err := values.Increment(&i); err != nil {
+		t.Fatalf("unexpected error incrementing int, %v", err)
+	}
+	if i != 1 {
+		t.Fatalf("unexpected value found, expected 1, found %v", i)
+	}
+	if err := values.Increment(&u); err != nil {
+		t.Fatalf("unexpected error incrementing uint8, %v", err)
+	}
+	if err := values.Increment(&u); err == nil {
+		t.Fatalf("expected error incrementing beyond max uint8")
+	}
+	var s string
+	if err := values.Increment(&s); err == nil {
+		t.Fatalf("expected error incrementing string")
+	}
+}